@@ -5,19 +5,26 @@ import (
 	"tools/net"
 	"tools/util"
 
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
+	"os"
 	"reflect"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/btcsuite/btcd/btcutil/base58"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/status-im/keycard-go/hexutils"
@@ -31,15 +38,44 @@ type Contract struct {
 	} `json:"abi"`
 }
 
+// zeroCaller is the base58 zero address, the caller of the constant calls without a from address
+const zeroCaller = "T9yD14Nj9j7xAB4dbGeiX9h8unkKHxuWwb"
+
 var (
+	callAbiFlag = &cli.StringFlag{
+		Name:  "abi",
+		Usage: "local ABI file or dir, plain ABI json or Hardhat/Foundry/Truffle artifacts",
+	}
+	callCacheTTLFlag = &cli.DurationFlag{
+		Name:  "cache-ttl",
+		Value: 24 * time.Hour,
+		Usage: "reuse the ABI fetched within the duration, 0 to always fetch",
+	}
+	decimalsFlag = &cli.IntFlag{
+		Name:  "decimals",
+		Value: -1,
+		Usage: "render uint amounts as fixed-point values with the decimals",
+	}
+	tokenFlag = &cli.StringFlag{
+		Name:  "token",
+		Usage: "render uint amounts with decimals() and symbol() of the token (on main net if the command has no net)",
+	}
 	callCommand = cli.Command{
-		Name:  "call",
-		Usage: "Interact with contract on TRON network (main or nile)",
+		Name:      "call",
+		Usage:     "Interact with contract on TRON network (main or nile)",
+		ArgsUsage: "<main|nile> <contract> [abi-address]",
+		Flags: append([]cli.Flag{
+			callAbiFlag,
+			callCacheTTLFlag,
+			decimalsFlag,
+			tokenFlag,
+		}, keySourceFlags...),
 		Action: func(c *cli.Context) error {
 			if c.NArg() < 2 {
 				return errors.New("call command needs at least net and contract address")
 			}
-			domain := c.Args().Get(0)
+			network := c.Args().Get(0)
+			domain := network
 			if strings.Compare("main", domain) == 0 {
 				domain = "api"
 			} else if strings.Compare("nile", domain) == 0 {
@@ -48,126 +84,99 @@ var (
 				return errors.New("wrong net arg (main or nile)")
 			}
 			contractAddr := c.Args().Get(1)
-			abiAddr := c.Args().Get(1)
-			if c.NArg() > 2 {
-				abiAddr = c.Args().Get(2)
-			}
-			resData := net.Get(fmt.Sprintf("https://%s.trongrid.io/wallet/getcontract?value=%s&visible=true", domain, abiAddr))
-			var contract Contract
-			if err := json.Unmarshal(resData, &contract); err == nil {
-				if len(contract.Address) == 0 {
-					return errors.New("contract not exist, you may input wrong net")
+			// the address of the key is the from address, no need to input it for each call
+			var from string
+			if keySourceSet(c) {
+				key, err := loadPrivateKey(c, "")
+				if err != nil {
+					return err
 				}
-				for _, abi := range contract.ABI.Entries {
-					if _, ok := abi["stateMutability"]; ok {
-						abi["stateMutability"] = strings.ToLower(abi["stateMutability"].(string))
-					}
-					if _, ok := abi["type"]; ok {
-						abi["type"] = strings.ToLower(abi["type"].(string))
-					}
+				from = utils.ToBase58(crypto.PubkeyToAddress(key.PublicKey).Bytes())
+				fmt.Printf("[From]: %s\n", from)
+			}
+			var cache *utils.ABICache
+			if ttl := c.Duration(callCacheTTLFlag.Name); ttl > 0 {
+				var err error
+				if cache, err = utils.NewABICache(ttl); err != nil {
+					return err
 				}
-				data, _ := json.Marshal(contract.ABI.Entries)
-				contractABI, _ := abi.JSON(strings.NewReader(string(data)))
+			}
 
-				// first sort key
-				var keys []string
-				for k := range contractABI.Methods {
-					keys = append(keys, k)
+			var methods []abi.Method
+			seen := make(map[string]bool)
+			if c.IsSet(callAbiFlag.Name) {
+				contractABI, err := utils.LoadABIPath(c.String(callAbiFlag.Name))
+				if err != nil {
+					return err
 				}
-				sort.Strings(keys)
-
-				// second get each method according the sorted keys
-				var methods []abi.Method
-				for _, k := range keys {
-					methods = append(methods, contractABI.Methods[k])
-					fmt.Printf("%2d. %s\n", len(methods), contractABI.Methods[k].Sig)
+				methods = appendMethods(methods, seen, contractABI, "")
+			} else {
+				// the ABI of the first address wins, so the implementation comes before the proxy
+				sources := []string{contractAddr}
+				if c.NArg() > 2 {
+					sources = []string{c.Args().Get(2)}
+				} else if proxy := detectProxy(cache, network, domain, contractAddr); proxy != nil {
+					fmt.Printf("[Proxy]: %s\n", proxy.Kind)
+					fmt.Printf("  - implementation: %s\n", proxy.Implementation)
+					if len(proxy.Admin) != 0 {
+						fmt.Printf("  - admin: %s\n", proxy.Admin)
+					}
+					if len(proxy.Beacon) != 0 {
+						fmt.Printf("  - beacon: %s\n", proxy.Beacon)
+					}
+					sources = []string{proxy.Implementation, contractAddr}
+				}
+				for i, addr := range sources {
+					contractABI, err := fetchABI(cache, network, domain, addr)
+					if err != nil {
+						if i == len(sources)-1 {
+							return err
+						}
+						fmt.Printf("Fetch ABI of %s failed: %s\n", addr, err.Error())
+						continue
+					}
+					if len(sources) == 1 {
+						addr = ""
+					}
+					methods = appendMethods(methods, seen, contractABI, addr)
 				}
+			}
+			if len(methods) == 0 {
+				fmt.Println("No ABI found, call the contract by raw signature like `balanceOf(address)`.")
+			}
+			unit, err := setupAmountUnit(c, domain)
+			if err != nil {
+				return err
+			}
 
-				// next ask user to input the method index he wants to call
-				for {
+			// next ask user to input the method index (or signature) he wants to call
+			for {
+				var method abi.Method
+				if len(methods) == 0 {
+					fmt.Print("\nInput method signature to call: ")
+				} else {
 					fmt.Print("\nWhich method you want to call: ")
-					var index string
-					fmt.Scanln(&index)
-					index = strings.ToLower(index)
-					if index == "q" || index == "quit" || index == "exit" {
-						break
+				}
+				var index string
+				fmt.Scanln(&index)
+				if lower := strings.ToLower(index); lower == "q" || lower == "quit" || lower == "exit" {
+					break
+				}
+				if len(methods) == 0 {
+					var err error
+					if method, err = utils.MethodFromSignature(index); err != nil {
+						fmt.Printf("Input signature error: %s, try again.\n", err.Error())
+						continue
 					}
+				} else {
 					i, err := strconv.Atoi(index)
 					if i <= 0 || i > len(methods) || err != nil {
 						fmt.Println("Input index error, try again.")
 						continue
 					}
-					method := methods[i-1]
-					fmt.Printf("You choose method: [%s]\n", strings.ReplaceAll(method.String(), "function ", ""))
-					args := make([]interface{}, 0)
-					if len(method.Inputs) > 0 {
-						fmt.Println("Please input arguments:")
-						for _, inputType := range method.Inputs {
-							if len(inputType.Name) == 0 {
-								fmt.Printf(" - %s: ", inputType.Type)
-							} else {
-								fmt.Printf(" - %s: ", inputType.Name)
-							}
-							var input string
-							fmt.Scanln(&input)
-							if arg, err := pack(inputType.Type, input); err == nil {
-								args = append(args, arg)
-							}
-						}
-					}
-					calldata, err := method.Inputs.Pack(args...)
-					if err == nil {
-						var from string
-						if !method.IsConstant() {
-							fmt.Print("Please input from address (default zero address): ")
-							fmt.Scanln(&from)
-						}
-						if _, ok := utils.ToAddress(from); !ok {
-							from = "T9yD14Nj9j7xAB4dbGeiX9h8unkKHxuWwb"
-						}
-						res := net.Trigger(domain, contractAddr, from, method.Sig, hexutils.BytesToHex(calldata))
-						// print trigger result (default success)
-						if len(res.Result.Message) == 0 {
-							fmt.Println("[Trigger Result]\n  - " + "success")
-						} else {
-							fmt.Println("[Trigger Result]\n  - " + res.Result.Message)
-						}
-						// print energy used
-						fmt.Println("[Energy Used]\n  - " + strconv.Itoa(int(res.EnergyUsed)))
-						// print constant result
-						if len(res.ConstantResult) > 0 && len(res.ConstantResult[0]) > 0 {
-							fmt.Println("[Return Data]")
-							unpackResults, err := method.Outputs.Unpack(common.FromHex(res.ConstantResult[0]))
-							if err != nil {
-								fmt.Println(err.Error())
-							} else {
-								for i, result := range unpackResults {
-									name := method.Outputs[i].Name
-									if len(name) == 0 {
-										name = "result"
-									}
-									printSol(result, &method.Outputs[i].Type, name, i, 1)
-								}
-							}
-						}
-						// print logs
-						if len(res.Logs) != 0 {
-							fmt.Println("[Logs]")
-						}
-						for _, log := range res.Logs {
-							fmt.Println(log)
-						}
-						// print internal transactions
-						if len(res.InternalTxs) != 0 {
-							fmt.Println("[Internal Txs]")
-						}
-						for _, tx := range res.InternalTxs {
-							fmt.Println(tx)
-						}
-					} else {
-						fmt.Printf("Pack error: %s\n", err.Error())
-					}
+					method = methods[i-1]
 				}
+				callMethod(domain, contractAddr, from, method, unit)
 			}
 			return nil
 		},
@@ -215,9 +224,9 @@ var (
 			if !ok {
 				return errors.New("only accept input in hex")
 			}
-			var types string
+			var types, method string
 			if len(data)%32 == 4 {
-				method := net.QueryMethod(data[:4])
+				method = net.QueryMethod(data[:4])
 				if len(method) != 0 {
 					typesRegExp := regexp.MustCompile(`^\w+\((.*)\)$`)
 					types = typesRegExp.ReplaceAllString(method, "$1")
@@ -231,40 +240,84 @@ var (
 				return errors.New("data must be 32*N")
 			}
 			if types != "" {
-				return unpack(types, data)
+				return unpack(types, data, newArgPrinter(method))
 			} else {
-				fmt.Println("[each data word]:")
-				format := "0x%02x: %x\n"
-				if len(data) > 8*32 {
-					format = "0x%03x: %x\n"
-				}
-				for i := 0; i < len(data)/32; i++ {
-					fmt.Printf(format, i*32, data[i*32:i*32+32])
-				}
-				return nil
+				return printGuess(data)
+			}
+		},
+	}
+	abiGuessCommand = cli.Command{
+		Name:  "guess",
+		Usage: "Guess the layout of data without signature, annotate each 32bytes word with its role",
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return errors.New("guess subcommand only needs data arg")
+			}
+			data, ok := utils.FromHex(c.Args().Get(0))
+			if !ok {
+				return errors.New("only accept input in hex")
 			}
+			if len(data)%32 == 4 {
+				fmt.Printf("[selector]: %x\n", data[:4])
+				data = data[4:]
+			}
+			return printGuess(data)
 		},
 	}
+	abiPackedFlag = &cli.BoolFlag{
+		Name:  "packed",
+		Usage: "Use abi.encodePacked, tightly packed without selector",
+	}
+	abiNoSelectorFlag = &cli.BoolFlag{
+		Name:  "no-selector",
+		Usage: "Encode the parameters only, like abi.encode",
+	}
 	abiPackCommand = cli.Command{
-		Name:  "pack",
-		Usage: "Pack data with function and parameters",
+		Name:      "pack",
+		Usage:     "Pack data with function and parameters",
+		ArgsUsage: "<signature|types> [args...]",
+		Flags: []cli.Flag{
+			abiPackedFlag,
+			abiNoSelectorFlag,
+		},
 		Action: func(c *cli.Context) error {
 			if c.NArg() < 1 {
 				return errors.New("pack subcommand requires at least one function argument")
 			}
 			arg0, arg1 := c.Args().Get(0), c.Args().Tail()
-			data, err := utils.EncodeCallData(arg0, arg1)
-			if err != nil {
-				return err
+			switch {
+			case c.Bool(abiPackedFlag.Name):
+				data, err := utils.EncodePacked(arg0, arg1)
+				if err != nil {
+					return err
+				}
+				log.NewLog("packed", hexutil.Encode(data))
+				log.NewLog("keccak256", crypto.Keccak256Hash(data).Hex())
+			case c.Bool(abiNoSelectorFlag.Name):
+				data, err := utils.EncodeParams(arg0, arg1)
+				if err != nil {
+					return err
+				}
+				log.NewLog("encoded", hexutil.Encode(data))
+			default:
+				data, err := utils.EncodeCallData(arg0, arg1)
+				if err != nil {
+					return err
+				}
+				log.NewLog("calldata", data)
 			}
-
-			log.NewLog("calldata", data)
 			return nil
 		},
 	}
 	abiUnpackCommand = cli.Command{
-		Name:  "unpack",
-		Usage: "Unpack data with given types",
+		Name:      "unpack",
+		Usage:     "Unpack data with given types",
+		ArgsUsage: "<types> <data>",
+		Flags: []cli.Flag{
+			abiPackedFlag,
+			decimalsFlag,
+			tokenFlag,
+		},
 		Action: func(c *cli.Context) error {
 			if c.NArg() != 2 {
 				return errors.New("unpack subcommand needs data and type args")
@@ -274,39 +327,451 @@ var (
 			if !ok {
 				return errors.New("only accept data in hex")
 			}
-			return unpack(arg0, data)
+			unit, err := setupAmountUnit(c, "api")
+			if err != nil {
+				return err
+			}
+			if c.Bool(abiPackedFlag.Name) {
+				return unpackPacked(arg0, data, unit)
+			}
+			return unpack(arg0, data, argPrinter{unit: unit})
 		},
 	}
-	abi4bytesCommand = cli.Command{
-		Name:  "4bytes",
-		Usage: "Get 4bytes selector for given method or event",
+	abiCacheCommand = cli.Command{
+		Name:  "cache",
+		Usage: "Manage the ABI cache of call command",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List all cached ABIs",
+				Action: func(c *cli.Context) error {
+					cache, err := utils.NewABICache(callCacheTTLFlag.Value)
+					if err != nil {
+						return err
+					}
+					items, err := cache.List()
+					if err != nil {
+						return err
+					}
+					for _, item := range items {
+						age := time.Since(item.Fetched).Truncate(time.Second)
+						note := ""
+						if age > cache.TTL {
+							note = " (expired)"
+						}
+						fmt.Printf("%s %s %d bytes, fetched %s ago%s\n", item.Network, item.Address, item.Size, age, note)
+					}
+					fmt.Printf("Total: %d, dir: %s\n", len(items), cache.Dir)
+					return nil
+				},
+			},
+			{
+				Name:      "clear",
+				Usage:     "Remove the cached ABI of the address, or all cached ABIs",
+				ArgsUsage: "[address]",
+				Action: func(c *cli.Context) error {
+					cache, err := utils.NewABICache(callCacheTTLFlag.Value)
+					if err != nil {
+						return err
+					}
+					count, err := cache.Clear(c.Args().First())
+					fmt.Printf("Removed: %d\n", count)
+					return err
+				},
+			},
+		},
+	}
+	abiLayoutFlag = &cli.StringFlag{
+		Name:    "layout",
+		Aliases: []string{"l"},
+		Usage:   "solc storageLayout json (or artifact having it), then the arg is a variable path like `balances[T...].amount`",
+	}
+	abiSlotCommand = cli.Command{
+		Name:      "slot",
+		Usage:     "Compute storage slot for slot expression or variable path, print all named slots without arg",
+		ArgsUsage: "<expr|path>",
+		Flags: []cli.Flag{
+			abiLayoutFlag,
+		},
 		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				names := make([]string, 0, len(utils.NamedSlots))
+				for name := range utils.NamedSlots {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					log.NewLog(name, utils.NamedSlots[name].Bytes())
+				}
+				return nil
+			}
 			if c.NArg() != 1 {
+				return errors.New("slot subcommand needs only one expression or path arg")
+			}
+			if !c.IsSet(abiLayoutFlag.Name) {
+				slot, err := utils.ComputeSlot(c.Args().Get(0))
+				if err != nil {
+					return err
+				}
+				log.NewLog("slot", slot.Bytes())
+				log.NewLog("slot in dec", slot.Big().String())
+				// where elements of dynamic array and data of long bytes/string start
+				log.NewLog("data slot", crypto.Keccak256(slot.Bytes()))
+				return nil
+			}
+			data, err := os.ReadFile(c.String(abiLayoutFlag.Name))
+			if err != nil {
+				return err
+			}
+			layout, err := utils.LoadStorageLayout(data)
+			if err != nil {
+				return err
+			}
+			loc, err := layout.Resolve(c.Args().Get(0))
+			if err != nil {
+				return err
+			}
+			log.NewLog("type", loc.Type.Label)
+			log.NewLog("slot", loc.Slot.Bytes())
+			log.NewLog("offset", loc.Offset)
+			log.NewLog("size", loc.Size)
+			if loc.Type.Encoding == "bytes" || loc.Type.Encoding == "dynamic_array" {
+				log.NewLog("data slot", crypto.Keccak256(loc.Slot.Bytes()))
+			}
+			return nil
+		},
+	}
+	abi4bytesFileFlag = &cli.StringFlag{
+		Name:  "file",
+		Usage: "read the solidity declarations from the file, - for stdin",
+	}
+	abi4bytesCommand = cli.Command{
+		Name:      "4bytes",
+		Usage:     "Get selector, topic0 and interface id for given signature or solidity declarations",
+		ArgsUsage: "<signature|declarations>",
+		Flags:     []cli.Flag{abi4bytesFileFlag},
+		Action: func(c *cli.Context) error {
+			var input string
+			switch file := c.String(abi4bytesFileFlag.Name); {
+			case len(file) != 0 && c.NArg() != 0:
+				return errors.New("4bytes subcommand needs either --file or signature arg")
+			case file == "-":
+				data, err := io.ReadAll(os.Stdin)
+				if err != nil {
+					return err
+				}
+				input = string(data)
+			case len(file) != 0:
+				data, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				input = string(data)
+			case c.NArg() != 1:
 				return errors.New("4bytes subcommand needs func or event signature arg")
+			default:
+				input = c.Args().Get(0)
+			}
+			decls, structs, err := utils.ParseDeclarations(input)
+			if err != nil {
+				return err
+			}
+			if len(decls) == 0 && len(structs) == 0 {
+				// plain signature without keyword, can be either function or event
+				signature, err := utils.CanonicalSignature(strings.ReplaceAll(input, ";", ""))
+				if err != nil {
+					return err
+				}
+				log.NewLog("abi valid", signature)
+				selector := crypto.Keccak256([]byte(signature))
+				log.NewLog("event hex", selector)
+				log.NewLog("func hex", selector[:4])
+				return nil
+			}
+			names := make([]string, 0, len(structs))
+			for name := range structs {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				log.NewLog("struct "+name, structs[name])
+			}
+			functions := 0
+			for _, decl := range decls {
+				switch decl.Kind {
+				case "event":
+					log.NewLog("event", decl.Signature)
+					log.NewLog("topic0", decl.Topic())
+				default:
+					log.NewLog(decl.Kind, decl.Signature)
+					log.NewLog("selector", decl.Selector())
+				}
+				if decl.Kind == "function" {
+					functions++
+				}
+			}
+			if functions > 1 {
+				log.NewLog("interface id", utils.InterfaceID(decls))
 			}
-			signature := c.Args().Get(0)
-			// drop semicolon
-			signature = strings.ReplaceAll(signature, ";", "")
-			// drop indexed keywords
-			signature = strings.ReplaceAll(signature, "indexed", "")
-			// drop name for all params
-			nameRegExp := regexp.MustCompile(`\s*(\w+)\s+\w+\s*([,)])`)
-			signature = nameRegExp.ReplaceAllString(signature, "$1$2")
-			// expand all int|uint to int256|uint256
-			abbIntRegExp := regexp.MustCompile(`int([,)\s\[])`)
-			signature = abbIntRegExp.ReplaceAllString(signature, "int256$1")
-			// drop all whitespaces
-			signature = strings.ReplaceAll(signature, " ", "")
-			log.NewLog("abi valid", signature)
-			selector := crypto.Keccak256([]byte(signature))
-			log.NewLog("event hex", selector)
-			log.NewLog("func hex", selector[:4])
 			return nil
 		},
 	}
 )
 
-func unpack(types string, data []byte) error {
+// appendMethods appends the methods of the ABI sorted by name and prints them,
+// methods already seen are skipped, from is printed after each method if not empty
+func appendMethods(methods []abi.Method, seen map[string]bool, contractABI *abi.ABI, from string) []abi.Method {
+	// first sort key
+	var keys []string
+	for k := range contractABI.Methods {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// second get each method according the sorted keys
+	for _, k := range keys {
+		method := contractABI.Methods[k]
+		if seen[method.Sig] {
+			continue
+		}
+		seen[method.Sig] = true
+		methods = append(methods, method)
+		if len(from) != 0 {
+			fmt.Printf("%2d. %s - %s\n", len(methods), method.Sig, from)
+		} else {
+			fmt.Printf("%2d. %s\n", len(methods), method.Sig)
+		}
+	}
+	return methods
+}
+
+// callMethod triggers the method, from is prompted for non-constant methods if it's empty
+func callMethod(domain, contractAddr, from string, method abi.Method, unit *tokenAmount) {
+	fmt.Printf("You choose method: [%s]\n", strings.ReplaceAll(method.String(), "function ", ""))
+	args := make([]interface{}, 0)
+	if len(method.Inputs) > 0 {
+		fmt.Println("Please input arguments:")
+		for _, inputType := range method.Inputs {
+			if len(inputType.Name) == 0 {
+				fmt.Printf(" - %s: ", inputType.Type)
+			} else {
+				fmt.Printf(" - %s: ", inputType.Name)
+			}
+			var input string
+			fmt.Scanln(&input)
+			if arg, err := pack(inputType.Type, input); err == nil {
+				args = append(args, arg)
+			}
+		}
+	}
+	calldata, err := method.Inputs.Pack(args...)
+	if err != nil {
+		fmt.Printf("Pack error: %s\n", err.Error())
+		return
+	}
+	if len(from) == 0 && !method.IsConstant() {
+		fmt.Print("Please input from address (default zero address): ")
+		fmt.Scanln(&from)
+	}
+	if _, ok := utils.ToAddress(from); !ok {
+		from = zeroCaller
+	}
+	res := net.Trigger(domain, contractAddr, from, method.Sig, hexutils.BytesToHex(calldata))
+	if res == nil {
+		fmt.Println("[Trigger Result]\n  - request failed")
+		return
+	}
+	// print trigger result (default success)
+	if len(res.Result.Message) == 0 {
+		fmt.Println("[Trigger Result]\n  - " + "success")
+	} else {
+		fmt.Println("[Trigger Result]\n  - " + res.Result.Message)
+	}
+	// print energy used
+	fmt.Println("[Energy Used]\n  - " + strconv.Itoa(int(res.EnergyUsed)))
+	// print constant result
+	if len(res.ConstantResult) > 0 && len(res.ConstantResult[0]) > 0 {
+		returnData := common.FromHex(res.ConstantResult[0])
+		if len(method.Outputs) == 0 {
+			// called by raw signature, the outputs are unknown
+			printReturnData(returnData)
+		} else {
+			fmt.Println("[Return Data]")
+			unpackResults, err := method.Outputs.Unpack(returnData)
+			if err != nil {
+				fmt.Println(err.Error())
+			} else {
+				for i, result := range unpackResults {
+					name := method.Outputs[i].Name
+					if len(name) == 0 {
+						name = "result"
+					}
+					argPrinter{unit: unit}.printSol(result, &method.Outputs[i].Type, name, i, 1)
+				}
+			}
+		}
+	}
+	// print logs
+	if len(res.Logs) != 0 {
+		fmt.Println("[Logs]")
+	}
+	for _, log := range res.Logs {
+		fmt.Println(log)
+	}
+	// print internal transactions
+	if len(res.InternalTxs) != 0 {
+		fmt.Println("[Internal Txs]")
+	}
+	for _, tx := range res.InternalTxs {
+		fmt.Println(tx)
+	}
+}
+
+// fetchABI gets the ABI of the contract by `wallet/getcontract`, or from the cache if not expired.
+// Contracts without ABI are cached too, so they are not fetched again and again.
+func fetchABI(cache *utils.ABICache, network, domain, addr string) (*abi.ABI, error) {
+	if _, ok := utils.ToAddress(addr); !ok {
+		return nil, fmt.Errorf("invalid address %s", addr)
+	}
+	if cache != nil {
+		if data, ok := cache.Get(network, addr); ok {
+			return utils.ParseABI(data)
+		}
+	}
+	resData := net.Get(fmt.Sprintf("https://%s.trongrid.io/wallet/getcontract?value=%s&visible=true", domain, addr))
+	if resData == nil {
+		return nil, fmt.Errorf("fetch contract %s failed", addr)
+	}
+	var contract Contract
+	if err := json.Unmarshal(resData, &contract); err != nil {
+		return nil, err
+	}
+	if len(contract.Address) == 0 {
+		return nil, errors.New("contract not exist, you may input wrong net")
+	}
+	if contract.ABI.Entries == nil {
+		contract.ABI.Entries = make([]map[string]interface{}, 0)
+	}
+	data, err := json.Marshal(contract.ABI.Entries)
+	if err != nil {
+		return nil, err
+	}
+	if cache != nil {
+		if err := cache.Put(network, addr, data); err != nil {
+			fmt.Printf("Cache ABI of %s failed: %s\n", addr, err.Error())
+		}
+	}
+	return utils.ParseABI(data)
+}
+
+type proxyInfo struct {
+	Kind           string `json:"kind,omitempty"`
+	Implementation string `json:"implementation,omitempty"`
+	Admin          string `json:"admin,omitempty"`
+	Beacon         string `json:"beacon,omitempty"`
+}
+
+// detectProxy resolves the proxy of addr, the result is cached with the ABI so the later calls
+// don't repeat the lookups. A result with failed lookups is not cached.
+func detectProxy(cache *utils.ABICache, network, domain, addr string) *proxyInfo {
+	if cache != nil {
+		if data, ok := cache.GetProxy(network, addr); ok {
+			var proxy proxyInfo
+			if err := json.Unmarshal(data, &proxy); err == nil {
+				if len(proxy.Implementation) == 0 {
+					return nil
+				}
+				return &proxy
+			}
+		}
+	}
+	proxy, err := resolveProxy(domain, addr)
+	if cache != nil && err == nil {
+		cached := proxy
+		if cached == nil {
+			cached = &proxyInfo{}
+		}
+		if data, err := json.Marshal(cached); err == nil {
+			if err := cache.PutProxy(network, addr, data); err != nil {
+				fmt.Printf("Cache proxy of %s failed: %s\n", addr, err.Error())
+			}
+		}
+	}
+	return proxy
+}
+
+// resolveProxy detects the proxy by its well-known slots, then by the `implementation()` of itself,
+// returns nil if addr is not a proxy. All addresses are in base58. The error is the failed lookup,
+// the proxy found before it is still returned.
+func resolveProxy(domain, addr string) (*proxyInfo, error) {
+	addrBytes, ok := utils.ToAddress(addr)
+	if !ok {
+		return nil, fmt.Errorf("invalid address %s", addr)
+	}
+	hexAddr := fmt.Sprintf("0x%x", addrBytes)
+	impl, err := storageAddress(domain, hexAddr, "eip1967.implementation")
+	if err != nil {
+		return nil, err
+	}
+	if len(impl) != 0 {
+		proxy := &proxyInfo{Kind: "EIP-1967", Implementation: impl}
+		if proxy.Admin, err = storageAddress(domain, hexAddr, "eip1967.admin"); len(proxy.Admin) != 0 {
+			proxy.Kind = "OpenZeppelin transparent (EIP-1967)"
+		}
+		return proxy, err
+	}
+	beacon, err := storageAddress(domain, hexAddr, "eip1967.beacon")
+	if err != nil {
+		return nil, err
+	}
+	if len(beacon) != 0 {
+		if impl := constantAddress(domain, beacon, "implementation()"); len(impl) != 0 {
+			return &proxyInfo{Kind: "beacon (EIP-1967)", Implementation: impl, Beacon: beacon}, nil
+		}
+	}
+	if impl, err = storageAddress(domain, hexAddr, "eip1822.proxiable"); err != nil {
+		return nil, err
+	}
+	if len(impl) != 0 {
+		return &proxyInfo{Kind: "EIP-1822", Implementation: impl}, nil
+	}
+	// a contract returning itself by implementation() is not a proxy
+	if impl := constantAddress(domain, addr, "implementation()"); len(impl) != 0 && impl != utils.ToBase58(addrBytes) {
+		return &proxyInfo{Kind: "implementation()", Implementation: impl}, nil
+	}
+	return nil, nil
+}
+
+// storageAddress reads the address in the named slot, empty if zero
+func storageAddress(domain, hexAddr, slot string) (string, error) {
+	value, err := net.GetStorageAt(domain, hexAddr, utils.NamedSlots[slot].Bytes())
+	if err != nil {
+		return "", err
+	}
+	return wordAddress(value), nil
+}
+
+// constantAddress calls the method without args by a constant trigger, empty if failed or zero
+func constantAddress(domain, addr, method string) string {
+	res := net.Trigger(domain, addr, zeroCaller, method, "")
+	if res == nil || len(res.Result.Message) != 0 || len(res.ConstantResult) == 0 {
+		return ""
+	}
+	return wordAddress(common.FromHex(res.ConstantResult[0]))
+}
+
+func wordAddress(word []byte) string {
+	if len(word) == 0 || len(word) > 32 {
+		return ""
+	}
+	addr := common.BytesToAddress(word)
+	if addr == (common.Address{}) {
+		return ""
+	}
+	return utils.ToBase58(addr.Bytes())
+}
+
+func unpack(types string, data []byte, printer argPrinter) error {
 	args := abi.Arguments{}
 	for _, arg := range strings.Split(types, ",") {
 		solType, _ := abi.NewType(arg, "", nil)
@@ -314,9 +779,7 @@ func unpack(types string, data []byte) error {
 	}
 	if res, err := args.UnpackValues(data); err == nil {
 		fmt.Printf("[unpack result]:\n")
-		for i, r := range res {
-			printSol(r, &args[i].Type, "arg", i, 1)
-		}
+		printer.printArgs(args, res, "arg", 1)
 		fmt.Printf("[end unpack]\n")
 		return nil
 	} else {
@@ -324,6 +787,19 @@ func unpack(types string, data []byte) error {
 	}
 }
 
+func unpackPacked(types string, data []byte, unit *tokenAmount) error {
+	solTypes, values, err := utils.DecodePacked(types, data)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[unpack result]:\n")
+	for i := range solTypes {
+		argPrinter{unit: unit}.printSol(values[i], &solTypes[i], "arg", i, 1)
+	}
+	fmt.Printf("[end unpack]\n")
+	return nil
+}
+
 func pack(t abi.Type, v string) (interface{}, error) {
 	switch t.T {
 	case abi.AddressTy:
@@ -368,22 +844,27 @@ func pack(t abi.Type, v string) (interface{}, error) {
 	return nil, nil
 }
 
-func printSol(param interface{}, paramTy *abi.Type, name string, index, offset int) {
+func (p argPrinter) printSol(param interface{}, paramTy *abi.Type, name string, index, offset int) {
 	printSeparator(offset, "  ", "", "- ")
 	switch paramTy.T {
 	case abi.ArrayTy:
 		fmt.Printf("[%s-%02d]: %s\n", name, index, paramTy.String())
 		paramArray := reflect.ValueOf(param)
 		for i := 0; i < paramArray.Len(); i++ {
-			printSol(paramArray.Index(i).Interface(), paramTy.Elem, "array", i, offset+1)
+			p.printSol(paramArray.Index(i).Interface(), paramTy.Elem, "array", i, offset+1)
 		}
 	case abi.SliceTy:
 		fmt.Printf("[%s-%02d]: %s\n", name, index, paramTy.String())
 		paramSlice := reflect.ValueOf(param)
 		for i := 0; i < paramSlice.Len(); i++ {
-			printSol(paramSlice.Index(i).Interface(), paramTy.Elem, "slice", i, offset+1)
+			p.printSol(paramSlice.Index(i).Interface(), paramTy.Elem, "slice", i, offset+1)
+		}
+	case abi.BytesTy:
+		fmt.Printf("[%s-%02d]: %s, %#x\n", name, index, paramTy.String(), param)
+		if !p.multiSend || !p.printMultiSend(param.([]byte), offset+1) {
+			p.printNestedCall(param.([]byte), offset+1)
 		}
-	case abi.BytesTy, abi.FixedBytesTy:
+	case abi.FixedBytesTy:
 		fmt.Printf("[%s-%02d]: %s, %#x\n", name, index, paramTy.String(), param)
 	case abi.AddressTy:
 		fmt.Printf("[%s-%02d]: %s, %v - %s\n", name, index, paramTy.String(), param, base58.CheckEncode(param.(common.Address).Bytes(), 0x41))
@@ -417,13 +898,97 @@ func printSol(param interface{}, paramTy *abi.Type, name string, index, offset i
 		if len(paramBigInt.String()) >= 6 {
 			fmt.Printf(" (%d)", len(paramBigInt.String()))
 		}
+		if p.unit != nil && paramTy.T == abi.UintTy {
+			fmt.Printf(" = %s", p.unit.format(paramBigInt))
+		}
 		fmt.Println()
+	case abi.TupleTy:
+		fmt.Printf("[%s-%02d]: %s\n", name, index, paramTy.String())
+		paramTuple := reflect.Indirect(reflect.ValueOf(param))
+		for i, elemTy := range paramTy.TupleElems {
+			field := paramTy.TupleRawNames[i]
+			if len(field) == 0 {
+				field = "field"
+			}
+			p.printSol(paramTuple.Field(i).Interface(), elemTy, field, i, offset+1)
+		}
 	default:
 		fmt.Printf("[%s-%02d]: %s, %v\n", name, index, paramTy.String(), param)
 		// fmt.Printf("[Parameter-%d]: %T, %#x\n", index, param, param)
 	}
 }
 
+// tokenAmount renders the integer amount as fixed-point value of the token
+type tokenAmount struct {
+	Decimals int
+	Symbol   string
+}
+
+func (t *tokenAmount) format(n *big.Int) string {
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(t.Decimals)), nil)
+	integer, fraction := new(big.Int).QuoRem(new(big.Int).Abs(n), unit, new(big.Int))
+	text := formatBigInt(integer)
+	if n.Sign() < 0 {
+		text = "-" + text
+	}
+	if t.Decimals > 0 {
+		text += fmt.Sprintf(".%0*s", t.Decimals, fraction.String())
+	}
+	if len(t.Symbol) != 0 {
+		text += " " + t.Symbol
+	}
+	return text
+}
+
+// setupAmountUnit returns the unit of --decimals/--token, the token is read on domain. It's nil if
+// neither is set, so only the amounts asked for are rendered.
+func setupAmountUnit(c *cli.Context, domain string) (*tokenAmount, error) {
+	var unit *tokenAmount
+	if c.IsSet(tokenFlag.Name) {
+		info, err := tokenInfo(domain, c.String(tokenFlag.Name))
+		if err != nil && !c.IsSet(decimalsFlag.Name) {
+			return nil, err
+		}
+		unit = info
+	}
+	if c.IsSet(decimalsFlag.Name) {
+		if c.Int(decimalsFlag.Name) < 0 || c.Int(decimalsFlag.Name) > 77 {
+			return nil, errors.New("decimals should be in [0, 77]")
+		}
+		if unit == nil {
+			unit = new(tokenAmount)
+		}
+		unit.Decimals = c.Int(decimalsFlag.Name)
+	}
+	return unit, nil
+}
+
+// tokenInfo reads decimals() and symbol() of the token, symbol is optional and may be bytes32
+func tokenInfo(domain, token string) (*tokenAmount, error) {
+	res := net.Trigger(domain, token, zeroCaller, "decimals()", "")
+	if res == nil || len(res.Result.Message) != 0 || len(res.ConstantResult) == 0 {
+		return nil, fmt.Errorf("read decimals() of %s failed", token)
+	}
+	decimals := new(big.Int).SetBytes(common.FromHex(res.ConstantResult[0]))
+	if !decimals.IsUint64() || decimals.Uint64() > 77 {
+		return nil, fmt.Errorf("invalid decimals() of %s: %s", token, decimals)
+	}
+	unit := &tokenAmount{Decimals: int(decimals.Uint64())}
+	res = net.Trigger(domain, token, zeroCaller, "symbol()", "")
+	if res == nil || len(res.Result.Message) != 0 || len(res.ConstantResult) == 0 {
+		return unit, nil
+	}
+	data := common.FromHex(res.ConstantResult[0])
+	if len(data) == 32 {
+		unit.Symbol = string(bytes.TrimRight(data, "\x00"))
+	} else if len(data) > 64 {
+		if length := new(big.Int).SetBytes(data[32:64]); length.IsUint64() && 64+length.Uint64() <= uint64(len(data)) {
+			unit.Symbol = string(data[64 : 64+length.Uint64()])
+		}
+	}
+	return unit, nil
+}
+
 func printSeparator(repeat int, symbol, prefix, suffix string) {
 	fmt.Print(prefix)
 	for i := 0; i < repeat; i++ {
@@ -457,3 +1022,225 @@ func formatBigInt(n *big.Int) string {
 	}
 	return string(buf[i+1:])
 }
+
+// printGuess prints each data word with its guessed role, type and readable value
+func printGuess(data []byte) error {
+	guesses, err := utils.GuessLayout(data)
+	if err != nil {
+		return err
+	}
+	fmt.Println("[guess layout]:")
+	format := "0x%02x: %x %-7s %s\n"
+	if len(data) > 8*32 {
+		format = "0x%03x: %x %-7s %s\n"
+	}
+	for _, g := range guesses {
+		note := g.Note
+		if len(g.Type) != 0 {
+			note = g.Type + ", " + note
+		}
+		fmt.Printf(format, g.Offset, g.Word, g.Role, note)
+	}
+	return nil
+}
+
+// argPrinter prints the decoded values as tree. depth is the level of the nested calldata, and
+// multiSend is set for the args of `multiSend(bytes)` which are Safe MultiSend packed transactions.
+// The uint values are rendered with unit if it's set.
+type argPrinter struct {
+	depth     int
+	multiSend bool
+	unit      *tokenAmount
+}
+
+// multiSendSignature matches `multiSend(bytes)`, with or without the param name
+var multiSendSignature = regexp.MustCompile(`^multiSend\(bytes(\s+\w+)?\)$`)
+
+// newArgPrinter prints the args of the method signature, it can be empty if unknown
+func newArgPrinter(signature string) argPrinter {
+	return argPrinter{multiSend: multiSendSignature.MatchString(strings.TrimSpace(signature))}
+}
+
+// printArgs prints the unpacked args as tree, the `execute(bytes commands, bytes[] inputs)` of
+// universal routers gets each command decoded with its input
+func (p argPrinter) printArgs(args abi.Arguments, values []interface{}, name string, offset int) {
+	if commands, inputs, ok := routerExecuteArgs(args, values); ok {
+		p.printRouterCommands(commands, inputs, offset)
+		for i := 2; i < len(values); i++ {
+			p.printSol(values[i], &args[i].Type, name, i, offset)
+		}
+		return
+	}
+	for i, value := range values {
+		p.printSol(value, &args[i].Type, name, i, offset)
+	}
+}
+
+// maxCallDepth limits the recursion of nested calldata, like multicall in multicall
+const maxCallDepth = 4
+
+var (
+	methodCache     = make(map[string]string)
+	methodCacheLock sync.Mutex
+)
+
+// printNestedCall decodes the bytes as calldata with a resolvable selector, nothing is printed
+// if it doesn't look like one
+func (p argPrinter) printNestedCall(data []byte, offset int) {
+	if p.depth >= maxCallDepth {
+		return
+	}
+	// calldata is always the selector and 32 bytes words
+	if len(data) < 4 || (len(data)-4)%32 != 0 {
+		return
+	}
+	for _, signature := range queryMethodCached(data[:4]) {
+		method, err := utils.MethodFromSignature(signature)
+		if err != nil {
+			continue
+		}
+		values, err := method.Inputs.UnpackValues(data[4:])
+		if err != nil {
+			continue
+		}
+		printSeparator(offset, "  ", "", "- ")
+		fmt.Printf("[call]: %s\n", signature)
+		nested := newArgPrinter(signature)
+		nested.depth, nested.unit = p.depth+1, p.unit
+		nested.printArgs(method.Inputs, values, "arg", offset+1)
+		return
+	}
+}
+
+// printMultiSend prints the bytes as MultiSend packed transactions, false if they are not
+func (p argPrinter) printMultiSend(data []byte, offset int) bool {
+	txs, ok := parseMultiSend(data)
+	if !ok {
+		return false
+	}
+	nested := argPrinter{depth: p.depth + 1, unit: p.unit}
+	printSeparator(offset, "  ", "", "- ")
+	fmt.Printf("[multisend]: %d txs\n", len(txs))
+	for i, tx := range txs {
+		printSeparator(offset+1, "  ", "", "- ")
+		fmt.Printf("[tx-%02d]: %s to %s - %s, value %s\n", i, tx.operation(), tx.To.Hex(), utils.ToBase58(tx.To.Bytes()), tx.Value)
+		if len(tx.Data) != 0 {
+			printSeparator(offset+2, "  ", "", "- ")
+			fmt.Printf("[data]: %#x\n", tx.Data)
+			nested.printNestedCall(tx.Data, offset+3)
+		}
+	}
+	return true
+}
+
+// queryMethodCached returns the candidate signatures of the selector, each selector is queried once
+func queryMethodCached(selector []byte) []string {
+	key := hex.EncodeToString(selector)
+	methodCacheLock.Lock()
+	method, ok := methodCache[key]
+	methodCacheLock.Unlock()
+	if !ok {
+		method = net.QueryMethod(selector)
+		methodCacheLock.Lock()
+		methodCache[key] = method
+		methodCacheLock.Unlock()
+	}
+	if len(method) == 0 {
+		return nil
+	}
+	return strings.Split(method, ";")
+}
+
+// multiSendTx is a transaction of Gnosis Safe MultiSend, they are packed as
+// operation(1) | to(20) | value(32) | data length(32) | data
+type multiSendTx struct {
+	Operation byte
+	To        common.Address
+	Value     *big.Int
+	Data      []byte
+}
+
+func (tx *multiSendTx) operation() string {
+	if tx.Operation == 1 {
+		return "delegatecall"
+	}
+	return "call"
+}
+
+func parseMultiSend(data []byte) ([]*multiSendTx, bool) {
+	var txs []*multiSendTx
+	for len(data) > 0 {
+		if len(data) < 85 || data[0] > 1 {
+			return nil, false
+		}
+		length := new(big.Int).SetBytes(data[53:85])
+		if !length.IsUint64() || length.Uint64() > uint64(len(data)-85) {
+			return nil, false
+		}
+		end := 85 + int(length.Uint64())
+		txs = append(txs, &multiSendTx{
+			Operation: data[0],
+			To:        common.BytesToAddress(data[1:21]),
+			Value:     new(big.Int).SetBytes(data[21:53]),
+			Data:      data[85:end],
+		})
+		data = data[end:]
+	}
+	return txs, len(txs) != 0
+}
+
+// routerCommands are the input types of universal router commands, the others are printed in hex
+var routerCommands = map[byte]struct {
+	name   string
+	inputs string
+}{
+	0x00: {"V3_SWAP_EXACT_IN", "address,uint256,uint256,bytes,bool"},
+	0x01: {"V3_SWAP_EXACT_OUT", "address,uint256,uint256,bytes,bool"},
+	0x02: {"PERMIT2_TRANSFER_FROM", "address,address,uint160"},
+	0x04: {"SWEEP", "address,address,uint256"},
+	0x05: {"TRANSFER", "address,address,uint256"},
+	0x06: {"PAY_PORTION", "address,address,uint256"},
+	0x08: {"V2_SWAP_EXACT_IN", "address,uint256,uint256,address[],bool"},
+	0x09: {"V2_SWAP_EXACT_OUT", "address,uint256,uint256,address[],bool"},
+	0x0b: {"WRAP_ETH", "address,uint256"},
+	0x0c: {"UNWRAP_WETH", "address,uint256"},
+}
+
+// routerExecuteArgs matches `execute(bytes,bytes[])` and `execute(bytes,bytes[],uint256)`,
+// each command byte must have its input
+func routerExecuteArgs(args abi.Arguments, values []interface{}) ([]byte, [][]byte, bool) {
+	if len(values) < 2 || len(values) > 3 || args[0].Type.T != abi.BytesTy ||
+		args[1].Type.T != abi.SliceTy || args[1].Type.Elem.T != abi.BytesTy {
+		return nil, nil, false
+	}
+	commands, _ := values[0].([]byte)
+	inputs, _ := values[1].([][]byte)
+	return commands, inputs, len(commands) != 0 && len(commands) == len(inputs)
+}
+
+func (p argPrinter) printRouterCommands(commands []byte, inputs [][]byte, offset int) {
+	printSeparator(offset, "  ", "", "- ")
+	fmt.Printf("[commands]: %#x\n", commands)
+	for i, command := range commands {
+		printSeparator(offset+1, "  ", "", "- ")
+		// the highest bit allows the command to revert, the command type is in the lower 6 bits
+		spec, ok := routerCommands[command&0x3f]
+		if !ok {
+			fmt.Printf("[command-%02d]: 0x%02x, %#x\n", i, command, inputs[i])
+			continue
+		}
+		fmt.Printf("[command-%02d]: %s\n", i, spec.name)
+		var args abi.Arguments
+		for _, ty := range strings.Split(spec.inputs, ",") {
+			solType, _ := abi.NewType(ty, "", nil)
+			args = append(args, abi.Argument{Type: solType})
+		}
+		values, err := args.UnpackValues(inputs[i])
+		if err != nil {
+			printSeparator(offset+2, "  ", "", "- ")
+			fmt.Printf("[input]: %#x\n", inputs[i])
+			continue
+		}
+		p.printArgs(args, values, "input", offset+2)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/urfave/cli/v2"
+	"tools/net"
+	utils "tools/util"
+)
+
+var (
+	codeFromFlag = &cli.StringFlag{
+		Name:  "from",
+		Usage: "load the code of the address from the TRON network (main, nile, shasta) or the JSON-RPC url",
+	}
+	codeVerifyCommand = cli.Command{
+		Name:      "verify",
+		Usage:     "Compare the deployed code with the Hardhat/Foundry/solc artifact",
+		ArgsUsage: "<address> <artifact.json>",
+		Flags:     []cli.Flag{codeFromFlag},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 2 {
+				return errors.New("verify subcommand needs address and artifact args")
+			}
+			from := c.String(codeFromFlag.Name)
+			if len(from) == 0 {
+				from = "main"
+			}
+			code, creation, err := fetchCode(from, c.Args().Get(0), true)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(c.Args().Get(1))
+			if err != nil {
+				return err
+			}
+			artifact, err := utils.LoadArtifact(data)
+			if err != nil {
+				return err
+			}
+			return verifyCode(code, creation, artifact)
+		},
+	}
+)
+
+// fetchCode loads the runtime code by `eth_getCode` if from is a url, otherwise the `runtimecode` by
+// `wallet/getcontractinfo` of TRON. With creation the TRON `bytecode` by `wallet/getcontract` is
+// preferred, the returned flag tells if the code is the creation code and the constructor args.
+func fetchCode(from, address string, creation bool) ([]byte, bool, error) {
+	addr, ok := utils.ToAddress(address)
+	if !ok {
+		return nil, false, fmt.Errorf("invalid address %s", address)
+	}
+	if strings.HasPrefix(from, "http://") || strings.HasPrefix(from, "https://") {
+		code, err := net.GetCode(from, common.BytesToAddress(addr).Hex())
+		return code, false, err
+	}
+	if creation {
+		return net.GetContractCode(from, utils.ToBase58(addr))
+	}
+	code, err := net.GetRuntimeCode(from, utils.ToBase58(addr))
+	return code, false, err
+}
+
+// verifyCode compares the code with the runtime code of the artifact, or with the creation code if
+// the code is the TRON `bytecode`, the creation code followed by the constructor args. Metadata
+// trailers, immutables and library addresses are ignored.
+func verifyCode(code []byte, creation bool, artifact *utils.Artifact) error {
+	var deployed, expected, args []byte
+	if creation {
+		if len(artifact.Creation) == 0 {
+			return errors.New("the artifact has no creation code to compare with the deploy code")
+		}
+		creationMasks := append(utils.MetadataRanges(artifact.Creation), artifact.CreationMasks...)
+		codeCreation := code[:min(len(code), len(artifact.Creation))]
+		args = code[len(codeCreation):]
+		deployed = utils.MaskCode(codeCreation, creationMasks)
+		expected = utils.MaskCode(artifact.Creation, creationMasks)
+		fmt.Printf("[compared]: creation code, %d bytes and %d bytes constructor args\n", len(codeCreation), len(args))
+	} else {
+		codeRuntime, codeMeta := utils.SplitMetadata(code)
+		artifactRuntime, artifactMeta := utils.SplitMetadata(artifact.Runtime)
+		runtimeMasks := append(utils.MetadataRanges(artifactRuntime), artifact.RuntimeMasks...)
+		deployed = utils.MaskCode(codeRuntime, runtimeMasks)
+		expected = utils.MaskCode(artifactRuntime, runtimeMasks)
+		fmt.Printf("[compared]: runtime code, %d bytes\n", len(codeRuntime))
+		if codeMeta != nil || artifactMeta != nil {
+			fmt.Printf("[metadata]: deployed {%s}, artifact {%s}\n", formatMetadata(codeMeta), formatMetadata(artifactMeta))
+		}
+		for _, mask := range artifact.RuntimeMasks {
+			if mask.Start+mask.Length <= len(codeRuntime) {
+				fmt.Printf("[ignored]: [%d] 0x%x\n", mask.Start, codeRuntime[mask.Start:mask.Start+mask.Length])
+			}
+		}
+	}
+	if diff := firstDiff(deployed, expected); diff >= 0 {
+		deployedIns, expectedIns := firstDiffInstruction(deployed, expected)
+		fmt.Printf("[mismatch]: at byte %d\n", diff)
+		fmt.Printf("  - [deployed]: %s\n", deployedIns)
+		fmt.Printf("  - [artifact]: %s\n", expectedIns)
+		if !creation && !artifact.Immutables {
+			fmt.Printf("[hint]: the artifact has no immutableReferences, immutables can't be ignored, " +
+				"use the Foundry artifact or the solc standard json output if the contract has them\n")
+		}
+		return errors.New("code does not match the artifact")
+	}
+	fmt.Printf("[result]: match\n")
+	if len(args) != 0 {
+		if artifact.ABI == nil || len(artifact.ABI.Constructor.Inputs) == 0 {
+			fmt.Printf("[constructor args]: 0x%x\n", args)
+			return nil
+		}
+		values, err := artifact.ABI.Constructor.Inputs.UnpackValues(args)
+		if err != nil {
+			return fmt.Errorf("can't decode the constructor args: %v", err)
+		}
+		fmt.Printf("[constructor args]:\n")
+		argPrinter{}.printArgs(artifact.ABI.Constructor.Inputs, values, "arg", 1)
+	}
+	return nil
+}
+
+func formatMetadata(meta *utils.CodeMetadata) string {
+	if meta == nil {
+		return "none"
+	}
+	return meta.String()
+}
+
+// firstDiff returns the offset of the first different byte, -1 if they are the same
+func firstDiff(a, b []byte) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	if len(a) != len(b) {
+		return min(len(a), len(b))
+	}
+	return -1
+}
+
+// firstDiffInstruction disassembles both and formats the first different instructions
+func firstDiffInstruction(a, b []byte) (string, string) {
+	da, db := utils.Disassemble(a, opCodeDefined), utils.Disassemble(b, opCodeDefined)
+	for i := 0; i < len(da.Instructions) || i < len(db.Instructions); i++ {
+		if i >= len(da.Instructions) {
+			return "end of code", formatInstruction(db, db.Instructions[i])
+		}
+		if i >= len(db.Instructions) {
+			return formatInstruction(da, da.Instructions[i]), "end of code"
+		}
+		x, y := da.Instructions[i], db.Instructions[i]
+		if x.PC != y.PC || x.Op != y.Op || x.Undefined != y.Undefined || !bytes.Equal(x.Arg, y.Arg) {
+			return formatInstruction(da, x), formatInstruction(db, y)
+		}
+	}
+	return "", ""
+}
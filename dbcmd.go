@@ -1,14 +1,26 @@
 package main
 
 import (
+	"tools/log"
+	"tools/net"
+	"tools/store"
+	"tools/util"
+
 	"bytes"
 	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/btcsuite/btcd/btcutil/base58"
@@ -19,12 +31,51 @@ import (
 	"golang.org/x/crypto/sha3"
 )
 
-const (
-	LatestBlockNumKey  = "latest_block_header_number"
-	LatestBlockHashKey = "latest_block_header_hash"
-)
-
 var (
+	dbDataDirFlag = &cli.StringFlag{
+		Name:    "datadir",
+		Aliases: []string{"d"},
+		Usage:   "java-tron output dir (or its `database` dir), then db args can be store names",
+	}
+	dbEngineFlag = &cli.StringFlag{
+		Name:  "engine",
+		Usage: "store engine: " + strings.Join([]string{store.EngineLevelDB, store.EngineRocksDB}, "|") + " (read from engine.properties if not set)",
+	}
+	dbKeyFormatFlag = &cli.StringFlag{
+		Name:    "key-format",
+		Aliases: []string{"k"},
+		Usage:   "format of key args: " + strings.Join(store.KeyFormats(), "|") + " (auto-detect if not set)",
+	}
+	dbPrefixFlag = &cli.StringFlag{
+		Name:  "prefix",
+		Usage: "only handle the keys with the prefix, decoded by --key-format",
+	}
+	dbPrefixesFlag = &cli.StringSliceFlag{
+		Name:  "prefix",
+		Usage: "only export the keys with the prefix, can be repeated, decoded by --key-format",
+	}
+	dbStartFlag = &cli.StringFlag{
+		Name:  "start",
+		Usage: "export from the key (included), decoded by --key-format",
+	}
+	dbEndFlag = &cli.StringFlag{
+		Name:  "end",
+		Usage: "export until the key (excluded), decoded by --key-format",
+	}
+	dbSnapshotFormatFlag = &cli.StringFlag{
+		Name:  "format",
+		Value: store.SnapshotNDJSON,
+		Usage: "snapshot format: " + store.SnapshotNDJSON + "|" + store.SnapshotBinary,
+	}
+	dbValueFormatFlag = &cli.StringFlag{
+		Name:  "value-format",
+		Usage: "add decoded value text to NDJSON: " + strings.Join(store.KeyFormats(), "|"),
+	}
+	dbOutputFlag = &cli.StringFlag{
+		Name:    "output",
+		Aliases: []string{"o"},
+		Usage:   "output file, default stdout",
+	}
 	dbValueTypeFlag = &cli.StringFlag{Name: "type, t"}
 	dbCountCommand  = cli.Command{
 		Name:  "count",
@@ -33,7 +84,11 @@ var (
 			if c.NArg() != 1 {
 				return errors.New("count command needs db path arg")
 			}
-			return countDb(c.Args().Get(0))
+			dbPath, err := storePath(c, c.Args().Get(0))
+			if err != nil {
+				return err
+			}
+			return countDb(c, dbPath)
 		},
 	}
 	dbGetCommand = cli.Command{
@@ -41,15 +96,20 @@ var (
 		Usage: "Get value of the given key in db",
 		Flags: []cli.Flag{
 			dbValueTypeFlag,
+			dbKeyFormatFlag,
 		},
 		Subcommands: []*cli.Command{
 			{
 				Name:  "blocknum",
 				Usage: "Get current block number",
 				Action: func(c *cli.Context) error {
-					if value, err := queryValue("properties", []byte(LatestBlockNumKey)); err == nil {
+					dbPath, err := storePath(c, "properties")
+					if err != nil {
+						return err
+					}
+					if value, err := queryValue(c, dbPath, []byte(store.LatestBlockNumKey)); err == nil {
 						fmt.Printf("Key `%s` int value is %d\n",
-							LatestBlockNumKey,
+							store.LatestBlockNumKey,
 							int64(binary.BigEndian.Uint64(value)))
 						return nil
 					} else {
@@ -61,50 +121,60 @@ var (
 				Name:  "blockhash",
 				Usage: "Get current block hash",
 				Action: func(c *cli.Context) error {
-					if value, err := queryValue("properties", []byte(LatestBlockHashKey)); err == nil {
-						fmt.Printf("Key `%s` hex value is %x\n", LatestBlockHashKey, value)
+					dbPath, err := storePath(c, "properties")
+					if err != nil {
+						return err
+					}
+					if value, err := queryValue(c, dbPath, []byte(store.LatestBlockHashKey)); err == nil {
+						fmt.Printf("Key `%s` hex value is %x\n", store.LatestBlockHashKey, value)
 						return nil
 					} else {
 						return err
 					}
 				},
 			},
+			{
+				Name:  "props",
+				Usage: "Get all known dynamic properties",
+				Action: func(c *cli.Context) error {
+					dbPath, err := storePath(c, "properties")
+					if err != nil {
+						return err
+					}
+					return printProps(c, dbPath)
+				},
+			},
 		},
 		Action: func(c *cli.Context) error {
 			if c.NArg() < 2 {
 				return errors.New("get subcommand needs db-path and db-key args")
 			}
-			dbPath := c.Args().Get(0)
+			dbPath, err := storePath(c, c.Args().Get(0))
+			if err != nil {
+				return err
+			}
 			key := c.Args().Get(1)
-			var dbKey []byte
-			if strings.ContainsAny(key, "0x") {
-				if hexKey, err := hex.DecodeString(key[2:]); err == nil {
-					dbKey = hexKey
-				} else {
-					return err
-				}
-			} else if strings.HasPrefix(key, "T") && len(key) == 34 {
-				if decoded, _, err := base58.CheckDecode(key); err == nil {
-					dbKey = decoded
-				} else {
-					return err
-				}
-			} else {
-				dbKey = []byte(key)
+			dbKey, err := store.DecodeKey(c.String(dbKeyFormatFlag.Name), key)
+			if err != nil {
+				return err
 			}
-			if value, err := queryValue(dbPath, dbKey); err != nil {
+			value, err := queryValue(c, dbPath, dbKey)
+			// the digits are tried as uint64 too if the format is auto-detected
+			if numeric, ok := store.NumericKey(key); errors.Is(err, store.ErrNotFound) && ok && !c.IsSet(dbKeyFormatFlag.Name) {
+				value, err = queryValue(c, dbPath, numeric)
+			}
+			if err != nil {
 				return err
-			} else {
-				outputType := c.String("type")
-				switch outputType {
-				case "num", "number", "int", "int32", "int64":
-					fmt.Printf("Key `%s` int value is %d\n", key, int64(binary.BigEndian.Uint64(value)))
-				case "hex":
-				default:
-					fmt.Printf("Key `%s` hex value is %s\n", key, hex.EncodeToString(value))
-				}
-				return nil
 			}
+			outputType := c.String("type")
+			switch outputType {
+			case "num", "number", "int", "int32", "int64":
+				fmt.Printf("Key `%s` int value is %d\n", key, int64(binary.BigEndian.Uint64(value)))
+			case "hex":
+			default:
+				fmt.Printf("Key `%s` hex value is %s\n", key, hex.EncodeToString(value))
+			}
+			return nil
 		},
 	}
 	dbRootCommand = cli.Command{
@@ -114,7 +184,11 @@ var (
 			if c.NArg() != 1 {
 				return errors.New("hash subcommand needs db path arg")
 			}
-			if root, err := calcHash(c.Args().Get(0)); err == nil {
+			dbPath, err := storePath(c, c.Args().Get(0))
+			if err != nil {
+				return err
+			}
+			if root, err := calcHash(c, dbPath); err == nil {
 				fmt.Printf("Root is %s\n", hex.EncodeToString(root))
 				return nil
 			} else {
@@ -125,34 +199,279 @@ var (
 	dbPrintCommand = cli.Command{
 		Name:  "print",
 		Usage: "Print all key-value for given name db",
+		Flags: []cli.Flag{
+			dbKeyFormatFlag,
+			dbPrefixFlag,
+		},
 		Action: func(c *cli.Context) error {
 			if c.NArg() != 1 {
 				return errors.New("print subcommand needs db path arg")
 			}
-			return printDb(c.Args().Get(0))
+			dbPath, err := storePath(c, c.Args().Get(0))
+			if err != nil {
+				return err
+			}
+			prefix, err := prefixRange(c)
+			if err != nil {
+				return err
+			}
+			// keys are printed in hex unless the format is given explicitly
+			codec, err := store.GetKeyCodec(c.String(dbKeyFormatFlag.Name))
+			if err != nil {
+				return err
+			}
+			if len(c.String(dbKeyFormatFlag.Name)) == 0 {
+				codec, _ = store.GetKeyCodec("hex")
+			}
+			return printDb(c, dbPath, prefix, codec)
+		},
+	}
+	dbBlockCommand = cli.Command{
+		Name:  "block",
+		Usage: "Print the block with given num or hash from block store",
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return errors.New("block subcommand needs block num or hash arg")
+			}
+			block, err := getBlock(c, c.Args().Get(0))
+			if err != nil {
+				return err
+			}
+			printBlock(block)
+			return nil
+		},
+	}
+	dbTxCommand = cli.Command{
+		Name:  "tx",
+		Usage: "Print the tx and its result with given txid from trans store",
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return errors.New("tx subcommand needs txid arg")
+			}
+			txid, ok := fromHash(c.Args().Get(0))
+			if !ok {
+				return errors.New("txid must be 32 bytes hex")
+			}
+			return printTx(c, txid)
+		},
+	}
+	dbStorageCommand = cli.Command{
+		Name:  "storage",
+		Usage: "Get the value of contract storage slot (or slot expression) from storage-row store",
+		Flags: []cli.Flag{
+			dbKeyFormatFlag,
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 2 {
+				return errors.New("storage subcommand needs contract address and slot args")
+			}
+			addr, err := store.DecodeKey(c.String(dbKeyFormatFlag.Name), c.Args().Get(0))
+			if err != nil {
+				return err
+			}
+			// java-tron keys contract by the address with 0x41 prefix
+			if len(addr) == 20 {
+				addr = append([]byte{0x41}, addr...)
+			}
+			if len(addr) != 21 {
+				return errors.New("contract address must be 21 bytes (or 20 bytes without 0x41 prefix)")
+			}
+			slot, err := utils.ComputeSlot(c.Args().Get(1))
+			if err != nil {
+				return err
+			}
+			var contract *store.SmartContract
+			if data, err := queryStore(c, "contract", addr); err == nil {
+				if contract, err = store.DecodeSmartContract(data); err != nil {
+					return err
+				}
+			} else {
+				fmt.Printf("Contract not found in contract store (%s), treat it as version 0\n", err.Error())
+			}
+			rowKey := store.StorageRowKey(addr, slot, contract)
+			value, err := queryStore(c, "storage-row", rowKey)
+			if errors.Is(err, store.ErrNotFound) {
+				value = make([]byte, 32)
+			} else if err != nil {
+				return err
+			}
+			log.NewLog("contract", utils.ToBase58(addr))
+			if contract != nil {
+				log.NewLog("version", int(contract.Version))
+				if len(contract.TrxHash) != 0 {
+					log.NewLog("trx hash", contract.TrxHash)
+				}
+			}
+			log.NewLog("slot", slot.Bytes())
+			log.NewLog("row key", rowKey)
+			log.NewLog("value", value)
+			num := new(big.Int).SetBytes(value)
+			log.NewLog("in dec", fmt.Sprintf("%s (%s)", num.String(), formatBigInt(num)))
+			return nil
+		},
+	}
+	dbExportCommand = cli.Command{
+		Name:  "export",
+		Usage: "Export key-values of given db to NDJSON or binary snapshot",
+		Flags: []cli.Flag{
+			dbKeyFormatFlag,
+			dbPrefixesFlag,
+			dbStartFlag,
+			dbEndFlag,
+			dbSnapshotFormatFlag,
+			dbValueFormatFlag,
+			dbOutputFlag,
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return errors.New("export subcommand needs db path arg")
+			}
+			dbPath, err := storePath(c, c.Args().Get(0))
+			if err != nil {
+				return err
+			}
+			ranges, err := exportRanges(c)
+			if err != nil {
+				return err
+			}
+			// decoded text is only added when the format is given
+			var keyCodec, valueCodec store.KeyCodec
+			if c.IsSet(dbKeyFormatFlag.Name) {
+				if keyCodec, err = store.GetKeyCodec(c.String(dbKeyFormatFlag.Name)); err != nil {
+					return err
+				}
+			}
+			if c.IsSet(dbValueFormatFlag.Name) {
+				if valueCodec, err = store.GetKeyCodec(c.String(dbValueFormatFlag.Name)); err != nil {
+					return err
+				}
+			}
+			output := os.Stdout
+			if c.IsSet(dbOutputFlag.Name) {
+				if output, err = os.Create(c.String(dbOutputFlag.Name)); err != nil {
+					return err
+				}
+				defer output.Close()
+			}
+			writer, err := store.NewSnapshotWriter(output, c.String(dbSnapshotFormatFlag.Name), keyCodec, valueCodec)
+			if err != nil {
+				return err
+			}
+			count, root, err := exportDb(c, dbPath, ranges, writer)
+			if err != nil {
+				return err
+			}
+			// keep stdout clean for the snapshot itself
+			fmt.Fprintf(os.Stderr, "Exported %d items, root is %s\n", count, hex.EncodeToString(root))
+			return nil
+		},
+	}
+	dbImportCommand = cli.Command{
+		Name:  "import",
+		Usage: "Import the snapshot exported by `db export` into a new db",
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 2 {
+				return errors.New("import subcommand needs snapshot file and new db path args")
+			}
+			dbPath := c.Args().Get(1)
+			count, err := importDb(c.Args().Get(0), dbPath)
+			if err != nil {
+				return err
+			}
+			root, err := calcHash(c, dbPath)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Imported %d items, root is %s\n", count, hex.EncodeToString(root))
+			return nil
+		},
+	}
+	dbGrepCommand = cli.Command{
+		Name:      "grep",
+		Usage:     "Search hex, TRON address or ascii pattern in keys and values of given dbs",
+		ArgsUsage: "<pattern> <db-path...>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "ascii",
+				Usage: "treat the pattern as ascii string even if it looks like hex or address",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 {
+				return errors.New("grep subcommand needs pattern and db path args")
+			}
+			patterns := grepPatterns(c.Args().Get(0), c.Bool("ascii"))
+			names := c.Args().Tail()
+			if len(names) == 0 {
+				// search the whole datadir if no store is given
+				if len(c.String(dbDataDirFlag.Name)) == 0 {
+					return errors.New("grep subcommand needs db path args without --datadir")
+				}
+				dir, err := store.OpenDataDir(c.String(dbDataDirFlag.Name))
+				if err != nil {
+					return err
+				}
+				names = dir.Stores()
+			}
+			var dbPaths []string
+			for _, name := range names {
+				dbPath, err := storePath(c, name)
+				if err != nil {
+					return err
+				}
+				dbPaths = append(dbPaths, dbPath)
+			}
+			return grepDbs(c, dbPaths, patterns)
 		},
 	}
 	dbDiffCommand = cli.Command{
 		Name:  "diff",
 		Usage: "Diff for given db-A and db-B",
+		Flags: []cli.Flag{
+			dbKeyFormatFlag,
+			dbPrefixFlag,
+		},
 		Action: func(c *cli.Context) error {
 			if c.NArg() != 2 {
 				return errors.New("diff subcommand needs db-A and db-B path args")
 			}
-			return diffDb(c.Args().Get(0), c.Args().Get(1))
+			dbAPath, err := storePath(c, c.Args().Get(0))
+			if err != nil {
+				return err
+			}
+			dbBPath, err := storePath(c, c.Args().Get(1))
+			if err != nil {
+				return err
+			}
+			prefix, err := prefixRange(c)
+			if err != nil {
+				return err
+			}
+			return diffDb(c, dbAPath, dbBPath, prefix)
 		},
 	}
 )
 
-func dbOptions() *opt.Options {
-	return &opt.Options{
-		ErrorIfMissing: true,
-		ReadOnly:       true,
+// storePath resolves the store name in `--datadir`, without datadir the name is just a path
+func storePath(c *cli.Context, name string) (string, error) {
+	dataDir := c.String(dbDataDirFlag.Name)
+	if len(dataDir) == 0 {
+		return name, nil
 	}
+	dir, err := store.OpenDataDir(dataDir)
+	if err != nil {
+		return "", err
+	}
+	return dir.StorePath(name)
 }
 
-func countDb(dbPath string) error {
-	db, err := leveldb.OpenFile(dbPath, dbOptions())
+// openDb opens the store read-only in the engine of `--engine`, or the one in its engine.properties
+func openDb(c *cli.Context, dbPath string) (store.DB, error) {
+	return store.Open(dbPath, c.String(dbEngineFlag.Name))
+}
+
+func countDb(c *cli.Context, dbPath string) error {
+	db, err := openDb(c, dbPath)
 	if err != nil {
 		return err
 	}
@@ -185,7 +504,7 @@ func countDb(dbPath string) error {
 
 	count := 0
 	zero := 0
-	itr := db.NewIterator(nil, nil)
+	itr := db.NewIterator(nil)
 	defer itr.Release()
 	for itr.Next() {
 		count += 1
@@ -207,78 +526,520 @@ func allZero(s []byte) bool {
 	return true
 }
 
-func queryValue(dbPath string, key []byte) ([]byte, error) {
-	db, err := leveldb.OpenFile(dbPath, dbOptions())
+func queryValue(c *cli.Context, dbPath string, key []byte) ([]byte, error) {
+	db, err := openDb(c, dbPath)
 	if err != nil {
 		return nil, err
 	}
 	defer db.Close()
 
-	if value, err := db.Get(key, nil); err == nil {
+	if value, err := db.Get(key); err == nil {
 		return value, nil
 	} else {
 		return nil, err
 	}
 }
 
-func calcHash(dbPath string) ([]byte, error) {
-	db, err := leveldb.OpenFile(dbPath, dbOptions())
+// prefixRange returns the iterator range of `--prefix`, nil range means the whole db
+func prefixRange(c *cli.Context) (*store.Range, error) {
+	if !c.IsSet(dbPrefixFlag.Name) {
+		return nil, nil
+	}
+	prefix, err := store.DecodeKey(c.String(dbKeyFormatFlag.Name), c.String(dbPrefixFlag.Name))
 	if err != nil {
 		return nil, err
 	}
+	return store.BytesPrefix(prefix), nil
+}
+
+// exportRanges returns the ranges of `--prefix` (repeatable), or the range of `--start` and `--end`
+func exportRanges(c *cli.Context) ([]*store.Range, error) {
+	format := c.String(dbKeyFormatFlag.Name)
+	if prefixes := c.StringSlice(dbPrefixesFlag.Name); len(prefixes) != 0 {
+		if c.IsSet(dbStartFlag.Name) || c.IsSet(dbEndFlag.Name) {
+			return nil, errors.New("--prefix can not be used with --start or --end")
+		}
+		var keys [][]byte
+		for _, prefix := range prefixes {
+			key, err := store.DecodeKey(format, prefix)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+		// drop the prefixes covered by others, so no key is exported twice
+		sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+		var ranges []*store.Range
+		var last []byte
+		for _, key := range keys {
+			if last != nil && bytes.HasPrefix(key, last) {
+				continue
+			}
+			ranges = append(ranges, store.BytesPrefix(key))
+			last = key
+		}
+		return ranges, nil
+	}
+	slice := new(store.Range)
+	var err error
+	if c.IsSet(dbStartFlag.Name) {
+		if slice.Start, err = store.DecodeKey(format, c.String(dbStartFlag.Name)); err != nil {
+			return nil, err
+		}
+	}
+	if c.IsSet(dbEndFlag.Name) {
+		if slice.Limit, err = store.DecodeKey(format, c.String(dbEndFlag.Name)); err != nil {
+			return nil, err
+		}
+	}
+	return []*store.Range{slice}, nil
+}
+
+// grepPatterns converts the pattern to bytes, a TRON address gives both the 21 bytes and 20 bytes form
+func grepPatterns(pattern string, ascii bool) [][]byte {
+	if !ascii {
+		if data, ok := utils.FromHex(pattern); ok && len(data) != 0 {
+			return [][]byte{data}
+		}
+		if len(pattern) == 34 && pattern[0] == 'T' {
+			if addr, version, err := base58.CheckDecode(pattern); err == nil {
+				return [][]byte{append([]byte{version}, addr...), addr}
+			}
+		}
+	}
+	return [][]byte{[]byte(pattern)}
+}
+
+// grepMatches returns the offsets of patterns in data, the match of a shorter pattern
+// inside a longer one at the same place (20 bytes address in 21 bytes one) is dropped
+func grepMatches(data []byte, patterns [][]byte) []int {
+	var offsets []int
+	covered := make(map[int]bool)
+	for _, pattern := range patterns {
+		for start := 0; start+len(pattern) <= len(data); {
+			i := bytes.Index(data[start:], pattern)
+			if i < 0 {
+				break
+			}
+			offset := start + i
+			if !covered[offset+len(pattern)] {
+				offsets = append(offsets, offset)
+				covered[offset+len(pattern)] = true
+			}
+			start = offset + 1
+		}
+	}
+	sort.Ints(offsets)
+	return offsets
+}
+
+// grepDbs scans all dbs in parallel, at most NumCPU dbs at the same time
+func grepDbs(c *cli.Context, dbPaths []string, patterns [][]byte) error {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		total   int
+		errs    []error
+		workers = make(chan struct{}, runtime.NumCPU())
+	)
+	for _, dbPath := range dbPaths {
+		wg.Add(1)
+		go func(dbPath string) {
+			defer wg.Done()
+			workers <- struct{}{}
+			defer func() { <-workers }()
+
+			name := filepath.Base(dbPath)
+			count, err := grepDb(c, dbPath, patterns, func(key []byte, inKey bool, offset int) {
+				where := "value"
+				if inKey {
+					where = "key"
+				}
+				mu.Lock()
+				fmt.Printf("%s %x %s@%d\n", name, key, where, offset)
+				mu.Unlock()
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			total += count
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		}(dbPath)
+	}
+	wg.Wait()
+	fmt.Printf("Total matches: %d\n", total)
+	return errors.Join(errs...)
+}
+
+func grepDb(c *cli.Context, dbPath string, patterns [][]byte, found func(key []byte, inKey bool, offset int)) (int, error) {
+	db, err := openDb(c, dbPath)
+	if err != nil {
+		return 0, err
+	}
 	defer db.Close()
 
-	blackhole, _ := hex.DecodeString("4177944D19C052B73EE2286823AA83F8138CB7032F")
-	hash := common.Hash{}.Bytes()
-	itr := db.NewIterator(nil, nil)
+	count := 0
+	itr := db.NewIterator(nil)
 	defer itr.Release()
 	for itr.Next() {
-		if !bytes.Equal(itr.Key(), blackhole) {
-			// fmt.Printf("%s\n", hex.EncodeToString(itr.Key()))
-			hasher := sha3.NewLegacyKeccak256()
-			hasher.Write(hash)
-			hasher.Write(itr.Key())
-			hasher.Write(itr.Value())
-			hash = hasher.Sum(nil)
+		for _, offset := range grepMatches(itr.Key(), patterns) {
+			found(itr.Key(), true, offset)
+			count += 1
+		}
+		for _, offset := range grepMatches(itr.Value(), patterns) {
+			found(itr.Key(), false, offset)
+			count += 1
+		}
+	}
+	return count, itr.Error()
+}
+
+func queryStore(c *cli.Context, name string, key []byte) ([]byte, error) {
+	dbPath, err := storePath(c, name)
+	if err != nil {
+		return nil, err
+	}
+	return queryValue(c, dbPath, key)
+}
+
+// fromHash accepts 32 bytes hash with or without 0x prefix
+func fromHash(s string) ([]byte, bool) {
+	hash, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"))
+	return hash, err == nil && len(hash) == 32
+}
+
+func longKey(num int64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(num))
+	return key
+}
+
+func getBlock(c *cli.Context, numOrHash string) (*store.Block, error) {
+	blockId, ok := fromHash(numOrHash)
+	if !ok {
+		num, err := strconv.ParseInt(numOrHash, 10, 64)
+		if err != nil {
+			return nil, errors.New("block arg must be num or 32 bytes hash")
+		}
+		if blockId, err = queryStore(c, "block-index", longKey(num)); err != nil {
+			return nil, fmt.Errorf("block %d not found in block-index: %w", num, err)
+		}
+	}
+	data, err := queryStore(c, "block", blockId)
+	if err != nil {
+		return nil, fmt.Errorf("block %x not found: %w", blockId, err)
+	}
+	return store.DecodeBlock(data)
+}
+
+func printBlock(block *store.Block) {
+	header := &block.Header
+	fmt.Printf("[Block]: %d\n", header.Number)
+	fmt.Printf("[ID]: %x\n", header.ID())
+	fmt.Printf("[Parent]: %x\n", header.ParentHash)
+	fmt.Printf("[Time]: %s (%d)\n", time.UnixMilli(header.Timestamp).Format("2006-01-02 15:04:05"), header.Timestamp)
+	fmt.Printf("[Witness]: %s\n", utils.ToBase58(header.WitnessAddress))
+	fmt.Printf("[TxTrieRoot]: %x\n", header.TxTrieRoot)
+	if len(header.AccountStateRoot) != 0 {
+		fmt.Printf("[AccountStateRoot]: %x\n", header.AccountStateRoot)
+	}
+	fmt.Printf("[Version]: %d\n", header.Version)
+	fmt.Printf("[Txs]: %d\n", len(block.Transactions))
+	width := strconv.Itoa(len(strconv.Itoa(len(block.Transactions))))
+	for i, tx := range block.Transactions {
+		fmt.Printf("%"+width+"d %x %s", i+1, tx.ID(), tx.ContractRet())
+		if len(tx.Contracts) != 0 {
+			contract := &tx.Contracts[0]
+			fmt.Printf(" %s %s", contract.TypeName(), utils.ToBase58(contract.Owner()))
+			if to := contract.To(); len(to) != 0 {
+				fmt.Printf(" -> %s", utils.ToBase58(to))
+			}
+			// only selector here, querying the method for each tx is too slow for a whole block
+			if data := contract.CallData(); len(data) >= 4 {
+				fmt.Printf(" %x", data[:4])
+			}
+		}
+		fmt.Println()
+	}
+}
+
+func printTx(c *cli.Context, txid []byte) error {
+	data, err := queryStore(c, "trans", txid)
+	if err != nil {
+		return fmt.Errorf("tx %x not found in trans: %w", txid, err)
+	}
+	var tx *store.Transaction
+	blockNum := int64(-1)
+	if len(data) == 8 {
+		// trans store only keeps the block num since the tx is already in block
+		blockNum = int64(binary.BigEndian.Uint64(data))
+		block, err := getBlock(c, strconv.FormatInt(blockNum, 10))
+		if err != nil {
+			return err
+		}
+		for _, blockTx := range block.Transactions {
+			if bytes.Equal(blockTx.ID(), txid) {
+				tx = blockTx
+				break
+			}
+		}
+		if tx == nil {
+			return fmt.Errorf("tx %x not found in block %d", txid, blockNum)
+		}
+	} else if tx, err = store.DecodeTransaction(data); err != nil {
+		return err
+	}
+	info := getTxInfo(c, txid, blockNum)
+	if info != nil {
+		blockNum = info.BlockNumber
+	}
+
+	fmt.Printf("[Tx]: %x\n", tx.ID())
+	if blockNum >= 0 {
+		fmt.Printf("[Block]: %d\n", blockNum)
+	}
+	fmt.Printf("[Time]: %s (%d)\n", time.UnixMilli(tx.Timestamp).Format("2006-01-02 15:04:05"), tx.Timestamp)
+	fmt.Printf("[Result]: %s\n", tx.ContractRet())
+	if len(tx.Contracts) == 0 {
+		return nil
+	}
+	contract := &tx.Contracts[0]
+	fmt.Printf("[Type]: %s\n", contract.TypeName())
+	fmt.Println("[From]:", utils.ToBase58(contract.Owner()))
+	if to := contract.To(); len(to) != 0 {
+		fmt.Println("[To]:", utils.ToBase58(to))
+	}
+	if value := contract.CallValue(); value != 0 {
+		fmt.Printf("[Value]: %d\n", value)
+	}
+	if tx.FeeLimit != 0 {
+		fmt.Printf("[FeeLimit]: %d\n", tx.FeeLimit)
+	}
+	if info != nil {
+		fmt.Printf("[Fee]: %d\n", info.Fee)
+		fmt.Printf("[Energy Used]: %d (fee %d, origin %d)\n",
+			info.Receipt.EnergyUsageTotal, info.Receipt.EnergyFee, info.Receipt.OriginEnergyUsage)
+		fmt.Printf("[Net Used]: %d (fee %d)\n", info.Receipt.NetUsage, info.Receipt.NetFee)
+		if len(info.ResMessage) != 0 {
+			fmt.Printf("[Message]: %s\n", utils.ToReadableASCII(info.ResMessage))
 		}
+		if len(info.ContractResult) != 0 {
+			printReturnData(info.ContractResult[0])
+		}
+	}
+	if callData := contract.CallData(); len(callData) >= 4 {
+		if method := net.QueryMethod(callData[:4]); len(method) != 0 {
+			fmt.Println("[Method]: " + method)
+			printMethodArgs(method, callData)
+		} else {
+			fmt.Printf("[Selector]: %x\n", callData[:4])
+		}
+	}
+	if info != nil && len(info.Logs) != 0 {
+		fmt.Println("[Logs]")
+		for _, txLog := range info.Logs {
+			fmt.Printf("  - address: %s\n", utils.ToBase58(txLog.Address))
+			for i, topic := range txLog.Topics {
+				fmt.Printf("    topic-%d: 0x%x\n", i, topic)
+			}
+			fmt.Printf("    data: 0x%x\n", txLog.Data)
+		}
+	}
+	return nil
+}
+
+// getTxInfo looks up transactionHistoryStore first, then the transactionRetStore of the block,
+// returns nil if both stores have no info for the tx (e.g. the stores are disabled).
+func getTxInfo(c *cli.Context, txid []byte, blockNum int64) *store.TransactionInfo {
+	if data, err := queryStore(c, "transactionHistoryStore", txid); err == nil {
+		if info, err := store.DecodeTransactionInfo(data); err == nil {
+			return info
+		}
+	}
+	if blockNum < 0 {
+		return nil
+	}
+	if data, err := queryStore(c, "transactionRetStore", longKey(blockNum)); err == nil {
+		if infos, err := store.DecodeTransactionRet(data); err == nil {
+			for _, info := range infos {
+				if bytes.Equal(info.ID, txid) {
+					return info
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func printProps(c *cli.Context, dbPath string) error {
+	db, err := openDb(c, dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, prop := range store.DynamicProperties {
+		if value, err := db.Get([]byte(prop.Key)); err == nil {
+			log.NewLog(prop.Key, prop.Decode(value))
+		} else if !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+func calcHash(c *cli.Context, dbPath string) ([]byte, error) {
+	db, err := openDb(c, dbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	hasher := newDbHasher()
+	itr := db.NewIterator(nil)
+	defer itr.Release()
+	for itr.Next() {
+		hasher.add(itr.Key(), itr.Value())
+	}
+	return hasher.hash, itr.Error()
+}
+
+var blackhole, _ = hex.DecodeString("4177944D19C052B73EE2286823AA83F8138CB7032F")
+
+// dbHasher chains all key-values by hash = keccak256(hash . key . value), the blackhole account is skipped
+type dbHasher struct {
+	hash []byte
+}
+
+func newDbHasher() *dbHasher {
+	return &dbHasher{hash: common.Hash{}.Bytes()}
+}
+
+func (h *dbHasher) add(key, value []byte) {
+	if !bytes.Equal(key, blackhole) {
+		hasher := sha3.NewLegacyKeccak256()
+		hasher.Write(h.hash)
+		hasher.Write(key)
+		hasher.Write(value)
+		h.hash = hasher.Sum(nil)
+	}
+}
+
+// exportDb writes the key-values in the ranges to the snapshot, returns the count and the root
+// which equals `db hash` of the db imported from the snapshot
+func exportDb(c *cli.Context, dbPath string, ranges []*store.Range, writer store.SnapshotWriter) (int, []byte, error) {
+	db, err := openDb(c, dbPath)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer db.Close()
+
+	count := 0
+	hasher := newDbHasher()
+	for _, slice := range ranges {
+		itr := db.NewIterator(slice)
+		for itr.Next() {
+			if err := writer.Write(itr.Key(), itr.Value()); err != nil {
+				itr.Release()
+				return count, nil, err
+			}
+			hasher.add(itr.Key(), itr.Value())
+			count += 1
+		}
+		itr.Release()
+		if err := itr.Error(); err != nil {
+			return count, nil, err
+		}
+	}
+	return count, hasher.hash, writer.Close()
+}
+
+// importDb creates a new LevelDB from the snapshot, it is the only place opening db in read-write mode
+func importDb(snapshotPath, dbPath string) (int, error) {
+	file, err := os.Open(snapshotPath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	// the whole snapshot is checked before anything is written
+	if err := store.ReadSnapshot(file, func(key, value []byte) error { return nil }); err != nil {
+		return 0, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	// only a new dir is created, so the cleanup on failure never removes other files
+	if _, err := os.Stat(dbPath); !errors.Is(err, os.ErrNotExist) {
+		return 0, fmt.Errorf("db path %s already exists", dbPath)
+	}
+	db, err := leveldb.OpenFile(dbPath, &opt.Options{ErrorIfExist: true})
+	if err != nil {
+		_ = os.RemoveAll(dbPath)
+		return 0, fmt.Errorf("create db %s failed: %w", dbPath, err)
+	}
+	count := 0
+	batch := new(leveldb.Batch)
+	err = store.ReadSnapshot(file, func(key, value []byte) error {
+		batch.Put(key, value)
+		count += 1
+		if batch.Len() >= 10000 {
+			if err := db.Write(batch, nil); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		return nil
+	})
+	if err == nil {
+		err = db.Write(batch, nil)
+	}
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// never leave a partial db behind
+		_ = os.RemoveAll(dbPath)
+		return 0, err
 	}
-	return hash, itr.Error()
+	return count, nil
 }
 
-func printDb(dbPath string) error {
-	db, err := leveldb.OpenFile(dbPath, dbOptions())
+func printDb(c *cli.Context, dbPath string, slice *store.Range, codec store.KeyCodec) error {
+	db, err := openDb(c, dbPath)
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	itr := db.NewIterator(nil, nil)
+	itr := db.NewIterator(slice)
 	defer itr.Release()
 	for itr.Next() {
-		fmt.Printf("%x\n", itr.Key())
+		fmt.Println(codec.Encode(itr.Key()))
 	}
 	return itr.Error()
 }
 
-func diffDb(dbAPath, dbBPath string) error {
-	dbA, err := leveldb.OpenFile(dbAPath, dbOptions())
+func diffDb(c *cli.Context, dbAPath, dbBPath string, slice *store.Range) error {
+	dbA, err := openDb(c, dbAPath)
 	if err != nil {
 		return err
 	}
 	defer dbA.Close()
-	dbB, err := leveldb.OpenFile(dbBPath, dbOptions())
+	dbB, err := openDb(c, dbBPath)
 	if err != nil {
 		return err
 	}
 	defer dbB.Close()
 
-	itr := dbA.NewIterator(nil, nil)
+	itr := dbA.NewIterator(slice)
 	defer itr.Release()
 	totalCount, notFoundCount := 0, 0
 	for itr.Next() {
 		totalCount += 1
 		key, aValue := itr.Key(), itr.Value()
-		if bValue, err := dbB.Get(key, nil); err == nil {
+		if bValue, err := dbB.Get(key); err == nil {
 			if !reflect.DeepEqual(aValue, bValue) {
 				fmt.Printf("Different: %x\n", key)
 			}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/holiman/uint256"
+	"github.com/urfave/cli/v2"
+	utils "tools/util"
+)
+
+// tronOpPops are the stack items popped by the TVM opcodes, each pushes one result
+var tronOpPops = map[vm.OpCode]int{
+	CALLTOKEN:              8,
+	TOKENBALANCE:           2,
+	CALLTOKENVALUE:         0,
+	CALLTOKENID:            0,
+	ISCONTRACT:             1,
+	FREEZE:                 3,
+	UNFREEZE:               2,
+	FREEZEEXPIRETIME:       2,
+	VOTEWITNESS:            4,
+	WITHDRAWREWARD:         0,
+	FREEZEBALANCEV2:        2,
+	UNFREEZEBALANCEV2:      2,
+	CANCELALLUNFREEZEV2:    0,
+	WITHDRAWEXPIREUNFREEZE: 0,
+	DELEGATERESOURCE:       3,
+	UNDELEGATERESOURCE:     3,
+}
+
+// tronOpSuccess are the TVM opcodes pushing 1 on success by default, the others push 0
+var tronOpSuccess = map[vm.OpCode]bool{
+	CALLTOKEN:           true,
+	FREEZE:              true,
+	UNFREEZE:            true,
+	VOTEWITNESS:         true,
+	FREEZEBALANCEV2:     true,
+	UNFREEZEBALANCEV2:   true,
+	CANCELALLUNFREEZEV2: true,
+	DELEGATERESOURCE:    true,
+	UNDELEGATERESOURCE:  true,
+}
+
+var (
+	evmCodeFlag = &cli.StringFlag{
+		Name:     "code",
+		Usage:    "runtime code in hex, or the file of hex code or Hardhat/Foundry/solc artifact",
+		Required: true,
+	}
+	evmInputFlag = &cli.StringFlag{
+		Name:  "input",
+		Usage: "calldata in hex, like the output of `abi pack`",
+	}
+	evmOutputFlag = &cli.StringFlag{
+		Name:  "output",
+		Usage: "types of the return data like `uint256,address`, not needed if the method is in the ABI",
+	}
+	evmCallerFlag = &cli.StringFlag{
+		Name:  "caller",
+		Usage: "caller address, the zero address by default",
+	}
+	evmValueFlag = &cli.StringFlag{
+		Name:  "value",
+		Usage: "call value in sun (wei)",
+	}
+	evmGasFlag = &cli.Uint64Flag{
+		Name:  "gas",
+		Value: 100_000_000,
+		Usage: "gas (energy) limit",
+	}
+	evmTraceFlag = &cli.BoolFlag{
+		Name:  "trace",
+		Usage: "print pc, opcode, stack, memory and storage of each step",
+	}
+	evmTronOpFlag = &cli.StringSliceFlag{
+		Name:  "tron-op",
+		Usage: "`NAME=value` pushed by the TVM opcode stub, like TOKENBALANCE=1000",
+	}
+	evmRunCommand = cli.Command{
+		Name:  "run",
+		Usage: "Run the calldata against the bytecode in a local EVM with TVM opcode stubs",
+		Flags: []cli.Flag{
+			evmCodeFlag,
+			evmInputFlag,
+			callAbiFlag,
+			evmOutputFlag,
+			evmCallerFlag,
+			evmValueFlag,
+			evmGasFlag,
+			evmTraceFlag,
+			evmTronOpFlag,
+		},
+		Action: func(c *cli.Context) error {
+			return runEVM(c)
+		},
+	}
+)
+
+func runEVM(c *cli.Context) error {
+	code, contractABI, err := loadRunCode(c.String(evmCodeFlag.Name))
+	if err != nil {
+		return err
+	}
+	if c.IsSet(callAbiFlag.Name) {
+		if contractABI, err = utils.LoadABIPath(c.String(callAbiFlag.Name)); err != nil {
+			return err
+		}
+	}
+	var input []byte
+	if c.IsSet(evmInputFlag.Name) {
+		var ok bool
+		if input, ok = utils.FromHex(c.String(evmInputFlag.Name)); !ok {
+			return errors.New("input is not in hex format")
+		}
+	}
+	stubs, err := tronOpStubs(c.StringSlice(evmTronOpFlag.Name))
+	if err != nil {
+		return err
+	}
+
+	cfg := &runtime.Config{Value: new(big.Int), GasLimit: c.Uint64(evmGasFlag.Name)}
+	if c.IsSet(evmCallerFlag.Name) {
+		caller, ok := utils.ToAddress(c.String(evmCallerFlag.Name))
+		if !ok {
+			return errors.New("invalid caller address")
+		}
+		cfg.Origin = common.BytesToAddress(caller)
+	}
+	if c.IsSet(evmValueFlag.Name) {
+		value, ok := math.ParseBig256(c.String(evmValueFlag.Name))
+		if !ok {
+			return errors.New("value should be uint256 in dec or hex")
+		}
+		cfg.Value = value
+	}
+	// the hooks aren't undone by reverts, the final values are read from the state after the run
+	type storageKey struct {
+		addr common.Address
+		slot common.Hash
+	}
+	original := make(map[storageKey]common.Hash)
+	hooks := &tracing.Hooks{
+		OnStorageChange: func(addr common.Address, slot common.Hash, prev, new common.Hash) {
+			if _, ok := original[storageKey{addr, slot}]; !ok {
+				original[storageKey{addr, slot}] = prev
+			}
+			if c.Bool(evmTraceFlag.Name) {
+				fmt.Printf("    storage: %s: %s -> %s\n", slot.Hex(), prev.Hex(), new.Hex())
+			}
+		},
+	}
+	if c.Bool(evmTraceFlag.Name) {
+		hooks.OnOpcode = traceStep()
+	}
+	cfg.EVMConfig.Tracer = hooks
+
+	ret, gasUsed, err := utils.RunCode(code, input, cfg, stubs)
+	switch {
+	case err == nil:
+		fmt.Println("[Result]\n  - success")
+	case errors.Is(err, vm.ErrExecutionReverted):
+		fmt.Println("[Result]\n  - revert")
+	default:
+		fmt.Printf("[Result]\n  - %s\n", err)
+	}
+	fmt.Printf("[Gas Used]\n  - %d\n", gasUsed)
+
+	var method *abi.Method
+	if contractABI != nil && len(input) >= 4 {
+		method, _ = contractABI.MethodById(input[:4])
+	}
+	if errors.Is(err, vm.ErrExecutionReverted) {
+		printRevertData(ret, contractABI)
+	} else if err == nil {
+		if err := printRunReturn(ret, method, c.String(evmOutputFlag.Name)); err != nil {
+			return err
+		}
+	}
+	var changed []storageKey
+	for key, prev := range original {
+		if cfg.State.GetState(key.addr, key.slot) != prev {
+			changed = append(changed, key)
+		}
+	}
+	if len(changed) != 0 {
+		fmt.Println("[Storage]")
+		sort.Slice(changed, func(i, j int) bool { return bytes.Compare(changed[i].slot[:], changed[j].slot[:]) < 0 })
+		for _, key := range changed {
+			fmt.Printf("  - %s: %s\n", key.slot.Hex(), cfg.State.GetState(key.addr, key.slot).Hex())
+		}
+	}
+	// the logs of the reverted calls are dropped by the state
+	logs := cfg.State.Logs()
+	if len(logs) != 0 {
+		fmt.Println("[Logs]")
+	}
+	for _, log := range logs {
+		fmt.Printf("  - topics: %v\n    data: 0x%x\n", log.Topics, log.Data)
+	}
+	return nil
+}
+
+// loadRunCode takes the code in hex, or the file of hex code or artifact, whose ABI is returned too
+func loadRunCode(arg string) ([]byte, *abi.ABI, error) {
+	if code, ok := utils.FromHex(arg); ok {
+		return code, nil, nil
+	}
+	data, err := os.ReadFile(arg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("code is neither hex nor a file: %v", err)
+	}
+	if json.Valid(data) {
+		artifact, err := utils.LoadArtifact(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(artifact.Runtime) == 0 {
+			return nil, nil, errors.New("no deployedBytecode in the artifact")
+		}
+		return artifact.Runtime, artifact.ABI, nil
+	}
+	text := strings.TrimSpace(string(data))
+	if !utils.Has0xPrefix(text) {
+		text = "0x" + text
+	}
+	code, ok := utils.FromHex(text)
+	if !ok {
+		return nil, nil, errors.New("code file is not in hex format")
+	}
+	return code, nil, nil
+}
+
+// tronOpStubs builds the TVM opcode stubs, values are `NAME=value` pushed instead of the defaults.
+// ISCONTRACT checks the code of the address by default.
+func tronOpStubs(values []string) ([]*utils.OpStub, error) {
+	configured := make(map[vm.OpCode]*uint256.Int)
+	for _, value := range values {
+		name, text, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, fmt.Errorf("tron-op %s should be NAME=value", value)
+		}
+		op, found := vm.OpCode(0), false
+		for candidate := range tronOpPops {
+			if strings.EqualFold(tronOpCodeToString[candidate], name) {
+				op, found = candidate, true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown TVM opcode %s", name)
+		}
+		n, ok := math.ParseBig256(text)
+		if !ok {
+			return nil, fmt.Errorf("value of %s should be uint256 in dec or hex", name)
+		}
+		configured[op] = uint256.MustFromBig(n)
+	}
+	var stubs []*utils.OpStub
+	for op, pops := range tronOpPops {
+		result := new(uint256.Int)
+		if tronOpSuccess[op] {
+			result.SetOne()
+		}
+		if value, ok := configured[op]; ok {
+			result = value
+		}
+		run := func(evm *vm.EVM, scope *vm.ScopeContext, args []uint256.Int) uint256.Int {
+			return *result
+		}
+		if _, ok := configured[op]; !ok && op == ISCONTRACT {
+			run = func(evm *vm.EVM, scope *vm.ScopeContext, args []uint256.Int) uint256.Int {
+				var isContract uint256.Int
+				if evm.StateDB.GetCodeSize(common.Address(args[0].Bytes20())) != 0 {
+					isContract.SetOne()
+				}
+				return isContract
+			}
+		}
+		// the energy of TVM isn't modeled, the stubs cost like a warm account access
+		stubs = append(stubs, &utils.OpStub{Op: op, Pops: pops, Gas: 100, Run: run})
+	}
+	return stubs, nil
+}
+
+// traceStep prints each step before it's executed, memory is printed only if changed
+func traceStep() func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	var lastMemory []byte
+	return func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+		fmt.Printf("[%d] 0x%02x %s gas %d cost %d depth %d\n", pc, op, opCodeName(vm.OpCode(op)), gas, cost, depth)
+		stack := scope.StackData()
+		items := make([]string, 0, len(stack))
+		for i := len(stack) - 1; i >= 0; i-- {
+			items = append(items, stack[i].Hex())
+		}
+		fmt.Printf("    stack: [%s]\n", strings.Join(items, ", "))
+		if memory := scope.MemoryData(); !bytes.Equal(memory, lastMemory) {
+			for offset := 0; offset < len(memory); offset += 32 {
+				fmt.Printf("    memory[0x%02x]: 0x%x\n", offset, memory[offset:min(offset+32, len(memory))])
+			}
+			lastMemory = append(lastMemory[:0], memory...)
+		}
+	}
+}
+
+// printRunReturn decodes the return data by the method outputs or the types, raw data if neither
+func printRunReturn(ret []byte, method *abi.Method, types string) error {
+	var outputs abi.Arguments
+	switch {
+	case len(types) != 0:
+		for _, ty := range strings.Split(types, ",") {
+			solType, err := abi.NewType(strings.TrimSpace(ty), "", nil)
+			if err != nil {
+				return err
+			}
+			outputs = append(outputs, abi.Argument{Type: solType})
+		}
+	case method != nil:
+		outputs = method.Outputs
+	default:
+		printReturnData(ret)
+		return nil
+	}
+	values, err := outputs.UnpackValues(ret)
+	if err != nil {
+		printReturnData(ret)
+		return err
+	}
+	fmt.Println("[Return Data]")
+	for i, value := range values {
+		name := outputs[i].Name
+		if len(name) == 0 {
+			name = "result"
+		}
+		argPrinter{}.printSol(value, &outputs[i].Type, name, i, 1)
+	}
+	return nil
+}
+
+// printRevertData decodes Error(string), Panic(uint256) and the custom errors of the ABI
+func printRevertData(ret []byte, contractABI *abi.ABI) {
+	if len(ret) < 4 {
+		printReturnData(ret)
+		return
+	}
+	var revertError *abi.Error
+	if contractABI != nil {
+		revertError, _ = contractABI.ErrorByID([4]byte(ret[:4]))
+	}
+	if revertError == nil {
+		stringTy, _ := abi.NewType("string", "", nil)
+		uint256Ty, _ := abi.NewType("uint256", "", nil)
+		for _, builtin := range []abi.Error{
+			abi.NewError("Error", abi.Arguments{{Name: "reason", Type: stringTy}}),
+			abi.NewError("Panic", abi.Arguments{{Name: "code", Type: uint256Ty}}),
+		} {
+			if bytes.Equal(builtin.ID[:4], ret[:4]) {
+				revertError = &builtin
+			}
+		}
+	}
+	if revertError == nil {
+		printReturnData(ret)
+		return
+	}
+	values, err := revertError.Inputs.UnpackValues(ret[4:])
+	if err != nil {
+		printReturnData(ret)
+		return
+	}
+	fmt.Printf("[Revert Data]\n  - %s\n", revertError.Sig)
+	for i, value := range values {
+		name := revertError.Inputs[i].Name
+		if len(name) == 0 {
+			name = "arg"
+		}
+		argPrinter{}.printSol(value, &revertError.Inputs[i].Type, name, i, 2)
+	}
+}
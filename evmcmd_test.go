@@ -0,0 +1,48 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	utils "tools/util"
+)
+
+// TestTronOpStubs runs the TVM opcodes on the local EVM, the stubs are installed into go-ethereum
+// internals by reflection, so a changed layout of the pinned version fails here.
+func TestTronOpStubs(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   string
+		values []string
+		want   uint64
+	}{
+		// PUSH0 PUSH0 TOKENBALANCE, then return the word on the stack top
+		{"tokenbalance default", "0x5f5fd15f5260205ff3", nil, 0},
+		{"tokenbalance configured", "0x5f5fd15f5260205ff3", []string{"TOKENBALANCE=1000"}, 1000},
+		// ADDRESS ISCONTRACT, the contract itself has code
+		{"iscontract self", "0x30d45f5260205ff3", nil, 1},
+		// PUSH1 1 ISCONTRACT, no code at address 1
+		{"iscontract empty", "0x6001d45f5260205ff3", nil, 0},
+		// the stub pops the args, PUSH1 7 stays under the result: 7 PUSH0 PUSH0 TOKENBALANCE POP
+		{"pops args", "0x60075f5fd1505f5260205ff3", []string{"TOKENBALANCE=1000"}, 7},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stubs, err := tronOpStubs(test.values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			code, _ := utils.FromHex(test.code)
+			cfg := &runtime.Config{Value: new(big.Int), GasLimit: 1_000_000}
+			ret, _, err := utils.RunCode(code, nil, cfg, stubs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := new(big.Int).SetBytes(ret); got.Cmp(new(big.Int).SetUint64(test.want)) != 0 {
+				t.Errorf("got %s, want %d, return data %s", got, test.want, common.Bytes2Hex(ret))
+			}
+		})
+	}
+}
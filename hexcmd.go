@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
@@ -167,68 +166,229 @@ var (
 			return nil
 		},
 	}
+	codeNoLookupFlag = &cli.BoolFlag{
+		Name:  "no-lookup",
+		Usage: "don't look up the names of the dispatcher selectors",
+	}
 	hexCodeCommand = cli.Command{
-		Name:  "code",
-		Usage: "Convert hex to bytecode",
+		Name:      "code",
+		Usage:     "Disassemble the EVM/TVM bytecode with basic blocks, jump targets, selectors and metadata",
+		ArgsUsage: "<bytecode|address>",
+		Flags:     []cli.Flag{codeNoLookupFlag, codeFromFlag},
 		Action: func(c *cli.Context) error {
 			if c.NArg() != 1 {
 				return errors.New("code command only needs single arg")
 			}
-			arg0 := c.Args().Get(0)
-
-			// check if input is in hex
-			if argBytes, ok := utils.FromHex(arg0); ok {
+			var code []byte
+			if c.IsSet(codeFromFlag.Name) {
+				var err error
+				if code, _, err = fetchCode(c.String(codeFromFlag.Name), c.Args().Get(0), false); err != nil {
+					return err
+				}
+			} else {
+				var ok bool
+				if code, ok = utils.FromHex(c.Args().Get(0)); !ok {
+					return errors.New("input is not in hex format")
+				}
+			}
+			runtime, meta := utils.SplitMetadata(code)
+			d := utils.Disassemble(runtime, opCodeDefined)
+			if meta != nil {
+				log.NewLog("metadata", fmt.Sprintf("%s (%d bytes after %d bytes code)", meta, len(meta.Raw), len(runtime)))
+			}
+			names := make(map[int]string)
+			if len(d.Selectors) != 0 {
 				var sb strings.Builder
-				for i := 0; i < len(argBytes); i++ {
-					opCode := vm.OpCode(argBytes[i])
-					if opCode >= CALLTOKEN && opCode <= UNDELEGATERESOURCE {
-						sb.WriteString(fmt.Sprintf("[%d] 0x%02x %s\n", i, argBytes[i], tronOpCodeToString[opCode]))
-					} else if opCode.IsPush() {
-						dataLen := opCode - vm.PUSH0
-						if i+1+int(dataLen) > len(argBytes) {
-							break
+				for _, selector := range d.Selectors {
+					name := fmt.Sprintf("0x%x", selector.ID)
+					if !c.Bool(codeNoLookupFlag.Name) {
+						if methods := queryMethodCached(selector.ID); len(methods) != 0 {
+							name += " " + strings.Join(methods, "; ")
 						}
-						data := hex.EncodeToString(argBytes[i+1 : i+1+int(dataLen)])
-						sb.WriteString(fmt.Sprintf("[%d] 0x%02x %s 0x%s\n", i, argBytes[i], opCode.String(), data))
-						i += int(dataLen) // skip the data bytes
-					} else {
-						if strings.Contains(opCode.String(), "not defined") {
-							break
-						}
-						sb.WriteString(fmt.Sprintf("[%d] 0x%02x %s\n", i, argBytes[i], opCode.String()))
 					}
+					names[selector.Entry] = name
+					sb.WriteString(fmt.Sprintf("\n  %s -> [%d]", name, selector.Entry))
 				}
-				log.NewLog("bytecode", "\n"+sb.String())
-			} else {
-				return errors.New("input is not in hex format")
+				log.NewLog("selectors", sb.String())
 			}
+			log.NewLog("bytecode", "\n"+formatDisassembly(d, names))
 			return nil
 		},
 	}
-	hexKeyCommand = cli.Command{
-		Name:  "key",
-		Usage: "Calculate the address corresponding to the private key",
+	hexCreateCommand = cli.Command{
+		Name:      "create",
+		Usage:     "Calculate the CREATE address of Ethereum deployer and nonce, or TRON txid and owner/nonce",
+		ArgsUsage: "<deployer|txid> <nonce|owner>",
 		Action: func(c *cli.Context) error {
-			if c.NArg() != 1 {
-				return errors.New("key command only needs single arg")
+			if c.NArg() != 2 {
+				return errors.New("create subcommand needs deployer (or txid) and nonce (or owner) args")
 			}
-			arg0 := c.Args().Get(0)
-
-			// check if input is in hex
-			if argBytes, ok := utils.FromHex(arg0); ok {
-				if len(argBytes) != 32 {
-					return errors.New("input should be 32 bytes hex string")
+			arg0, arg1 := c.Args().Get(0), c.Args().Get(1)
+			if txid, ok := utils.FromHex(arg0); ok && len(txid) == 32 {
+				// TRON derives from the (root) transaction id
+				if owner, ok := utils.ToAddress(arg1); ok {
+					log.NewLog("tron create", formatAddress(utils.TronCreateAddress(txid, owner)))
+					return nil
 				}
-				privateKey, err := crypto.ToECDSA(argBytes)
-				if err != nil {
-					return fmt.Errorf("invalid private key: %v", err)
+				nonce, ok := math.ParseUint64(arg1)
+				if !ok {
+					return errors.New("second arg should be the owner address or the internal nonce")
 				}
-				addr := crypto.PubkeyToAddress(privateKey.PublicKey)
-				log.NewLog("key addr", fmt.Sprintf("%s (%s)", base58.CheckEncode(addr.Bytes(), 0x41), addr.String()))
-			} else {
-				return errors.New("input is not in hex format")
+				log.NewLog("tron create", formatAddress(utils.TronInternalCreateAddress(txid, nonce)))
+				return nil
+			}
+			deployer, ok := utils.ToAddress(arg0)
+			if !ok {
+				return errors.New("first arg should be the deployer address or the 32 bytes txid")
+			}
+			nonce, ok := math.ParseUint64(arg1)
+			if !ok {
+				return errors.New("nonce should be uint64 in dec or hex")
 			}
+			log.NewLog("eth create", formatAddress(utils.CreateAddress(deployer, nonce)))
+			return nil
+		},
+	}
+	create2InitCodeFlag = &cli.BoolFlag{
+		Name:  "initcode",
+		Usage: "hash the 32 bytes arg as init code, it's taken as the init code hash by default",
+	}
+	hexCreate2Command = cli.Command{
+		Name:      "create2",
+		Usage:     "Calculate the CREATE2 address by both Ethereum (0xff) and TRON (0x41) rules",
+		ArgsUsage: "<deployer> <salt> <initcode|initcodehash>",
+		Flags:     []cli.Flag{create2InitCodeFlag},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 3 {
+				return errors.New("create2 subcommand needs deployer, salt and initcode args")
+			}
+			deployer, ok := utils.ToAddress(c.Args().Get(0))
+			if !ok {
+				return errors.New("deployer is not a valid address")
+			}
+			salt, err := utils.ParseSalt(c.Args().Get(1))
+			if err != nil {
+				return err
+			}
+			initCode, ok := utils.FromHex(c.Args().Get(2))
+			if !ok {
+				return errors.New("initcode is not in hex format")
+			}
+			codeHash := initCode
+			if len(initCode) != 32 || c.Bool(create2InitCodeFlag.Name) {
+				codeHash = crypto.Keccak256(initCode)
+			}
+			log.NewLog("salt", salt)
+			log.NewLog("initcode hash", codeHash)
+			log.NewLog("eth create2", formatAddress(utils.Create2Address(utils.Create2PrefixETH, deployer, salt, codeHash)))
+			log.NewLog("tron create2", formatAddress(utils.Create2Address(utils.Create2PrefixTRON, deployer, salt, codeHash)))
+			return nil
+		},
+	}
+	hexKeyCommand = cli.Command{
+		Name:      "key",
+		Usage:     "Calculate the address corresponding to the private key",
+		ArgsUsage: "[private-key]",
+		Flags:     keySourceFlags,
+		Action: func(c *cli.Context) error {
+			if c.NArg() > 1 {
+				return errors.New("key command only needs single arg")
+			}
+			privateKey, err := loadPrivateKey(c, c.Args().Get(0))
+			if err != nil {
+				return fmt.Errorf("invalid private key: %v", err)
+			}
+			addr := crypto.PubkeyToAddress(privateKey.PublicKey)
+			log.NewLog("key addr", fmt.Sprintf("%s (%s)", base58.CheckEncode(addr.Bytes(), 0x41), addr.String()))
 			return nil
 		},
 	}
 )
+
+// formatAddress formats the 20 bytes address like `hex addr` does, base58 (hex)
+func formatAddress(addr []byte) string {
+	return fmt.Sprintf("%s (%s)", utils.ToBase58(addr), common.BytesToAddress(addr).String())
+}
+
+// eofOpCodes are named by go-ethereum, but they are undefined in the legacy code of EVM and TVM
+var eofOpCodes = map[vm.OpCode]bool{
+	vm.RJUMP:           true,
+	vm.RJUMPI:          true,
+	vm.RJUMPV:          true,
+	vm.CALLF:           true,
+	vm.RETF:            true,
+	vm.JUMPF:           true,
+	vm.DUPN:            true,
+	vm.SWAPN:           true,
+	vm.EXCHANGE:        true,
+	vm.EOFCREATE:       true,
+	vm.RETURNCONTRACT:  true,
+	vm.RETURNDATALOAD:  true,
+	vm.EXTCALL:         true,
+	vm.EXTDELEGATECALL: true,
+	vm.EXTSTATICCALL:   true,
+}
+
+// opCodeName names the opcode by TVM first, the 0xd0 range is TRON's rather than EOF's
+func opCodeName(op vm.OpCode) string {
+	if name := tronOpCodeToString[op]; len(name) != 0 {
+		return name
+	}
+	return op.String()
+}
+
+func opCodeDefined(op vm.OpCode) bool {
+	if len(tronOpCodeToString[op]) != 0 {
+		return true
+	}
+	return !eofOpCodes[op] && !strings.Contains(op.String(), "not defined")
+}
+
+// formatDisassembly prints the instructions by basic blocks, names are the selectors of the entries
+func formatDisassembly(d *utils.Disassembly, names map[int]string) string {
+	var sb strings.Builder
+	for i, block := range d.Blocks {
+		if i != 0 {
+			sb.WriteString("\n")
+		}
+		entry := d.Instructions[block.Start]
+		sb.WriteString(fmt.Sprintf("-- block [%d]", entry.PC))
+		if len(block.From) != 0 {
+			sb.WriteString(" <-")
+			for _, from := range block.From {
+				sb.WriteString(fmt.Sprintf(" [%d]", from))
+			}
+		}
+		if name, ok := names[entry.PC]; ok {
+			sb.WriteString(" " + name)
+		}
+		if block.Dead {
+			sb.WriteString(" (unreachable)")
+		}
+		sb.WriteString("\n")
+		for _, ins := range d.Instructions[block.Start : block.End+1] {
+			sb.WriteString(formatInstruction(d, ins) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+func formatInstruction(d *utils.Disassembly, ins *utils.Instruction) string {
+	if ins.Undefined {
+		return fmt.Sprintf("[%d] 0x%02x INVALID", ins.PC, byte(ins.Op))
+	}
+	line := fmt.Sprintf("[%d] 0x%02x %s", ins.PC, byte(ins.Op), opCodeName(ins.Op))
+	if ins.Op.IsPush() && ins.Op != vm.PUSH0 {
+		line += fmt.Sprintf(" 0x%x", ins.Arg)
+		if ins.Truncated {
+			line += " (truncated)"
+		}
+	}
+	if ins.Target >= 0 {
+		line += fmt.Sprintf(" -> [%d]", ins.Target)
+		if !d.Jumpdests[ins.Target] {
+			line += " (not JUMPDEST)"
+		}
+	}
+	return line
+}
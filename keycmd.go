@@ -0,0 +1,416 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/urfave/cli/v2"
+	"tools/log"
+	utils "tools/util"
+)
+
+var (
+	keyEnvFlag = &cli.StringFlag{
+		Name:  "key-env",
+		Usage: "read the hex private key from the env variable",
+	}
+	keyFileFlag = &cli.StringFlag{
+		Name:  "key-file",
+		Usage: "read the hex private key from the file, - for stdin",
+	}
+	keystoreFlag = &cli.StringFlag{
+		Name:  "keystore",
+		Usage: "decrypt the private key from the V3 keystore file",
+	}
+	passwordFileFlag = &cli.StringFlag{
+		Name:  "password-file",
+		Usage: "read the keystore password from the file instead of prompt",
+	}
+	// keySourceFlags are shared by commands needing a private key, it's prompted if none is set
+	keySourceFlags = []cli.Flag{keyEnvFlag, keyFileFlag, keystoreFlag, passwordFileFlag}
+
+	keyDirFlag = &cli.StringFlag{
+		Name:  "dir",
+		Usage: "dir to write the keystore file (default: tt/keystore in the user config dir)",
+	}
+	keyLightFlag = &cli.BoolFlag{
+		Name:  "light",
+		Usage: "use light scrypt params, faster but weaker",
+	}
+	keyNewCommand = cli.Command{
+		Name:  "new",
+		Usage: "Generate a private key into an encrypted keystore",
+		Flags: []cli.Flag{keyDirFlag, keyLightFlag},
+		Action: func(c *cli.Context) error {
+			key, err := crypto.GenerateKey()
+			if err != nil {
+				return err
+			}
+			return writeKeystore(c, key)
+		},
+	}
+	keyImportCommand = cli.Command{
+		Name:  "import",
+		Usage: "Import a private key (prompted, or from --key-env/--key-file) into an encrypted keystore",
+		Flags: []cli.Flag{keyDirFlag, keyLightFlag, keyEnvFlag, keyFileFlag},
+		Action: func(c *cli.Context) error {
+			key, err := loadPrivateKey(c, "")
+			if err != nil {
+				return err
+			}
+			return writeKeystore(c, key)
+		},
+	}
+	keyExportCommand = cli.Command{
+		Name:      "export",
+		Usage:     "Decrypt the keystore and print the private key",
+		ArgsUsage: "<keystore-file>",
+		Flags:     []cli.Flag{passwordFileFlag},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return errors.New("export subcommand needs keystore file arg")
+			}
+			source := &utils.KeySource{Keystore: c.Args().Get(0), PasswordFile: c.String(passwordFileFlag.Name)}
+			key, err := source.Load()
+			if err != nil {
+				return err
+			}
+			addr := crypto.PubkeyToAddress(key.PublicKey)
+			log.NewLog("eth addr", addr.String())
+			log.NewLog("tron addr", base58.CheckEncode(addr.Bytes(), 0x41))
+			log.NewLog("private key", fmt.Sprintf("%x", crypto.FromECDSA(key)))
+			return nil
+		},
+	}
+)
+
+var (
+	mnemonicWordsFlag = &cli.IntFlag{
+		Name:  "words",
+		Value: 12,
+		Usage: "number of mnemonic words, 12, 15, 18, 21 or 24",
+	}
+	mnemonicFileFlag = &cli.StringFlag{
+		Name:  "mnemonic-file",
+		Usage: "read the mnemonic from the file, - for stdin, prompted if not set",
+	}
+	mnemonicPassphraseFlag = &cli.BoolFlag{
+		Name:  "passphrase",
+		Usage: "prompt the BIP39 passphrase",
+	}
+	hdCoinFlag = &cli.StringFlag{
+		Name:  "coin",
+		Value: "tron",
+		Usage: "derive along m/44'/195'/... (tron) or m/44'/60'/... (eth)",
+	}
+	hdAccountFlag = &cli.UintFlag{
+		Name:  "account",
+		Usage: "BIP44 account index",
+	}
+	hdStartFlag = &cli.UintFlag{
+		Name:  "start",
+		Usage: "first address index",
+	}
+	hdCountFlag = &cli.UintFlag{
+		Name:  "count",
+		Value: 5,
+		Usage: "number of addresses",
+	}
+	hdShowKeyFlag = &cli.BoolFlag{
+		Name:  "show-key",
+		Usage: "print the private keys too",
+	}
+	hdXpubFlag = &cli.StringFlag{
+		Name:  "xpub",
+		Usage: "derive <xpub>/0/i of the account xpub only, for watch-only address lists",
+	}
+	keyMnemonicCommand = cli.Command{
+		Name:  "mnemonic",
+		Usage: "BIP39 mnemonic and BIP44 HD derivation",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "new",
+				Usage: "Generate a BIP39 english mnemonic",
+				Flags: []cli.Flag{mnemonicWordsFlag},
+				Action: func(c *cli.Context) error {
+					mnemonic, err := utils.NewMnemonic(c.Int(mnemonicWordsFlag.Name))
+					if err != nil {
+						return err
+					}
+					log.NewLog("mnemonic", mnemonic)
+					return nil
+				},
+			},
+			{
+				Name:  "derive",
+				Usage: "Derive accounts from the mnemonic (prompted) or the account xpub",
+				Flags: []cli.Flag{
+					mnemonicFileFlag,
+					mnemonicPassphraseFlag,
+					hdCoinFlag,
+					hdAccountFlag,
+					hdStartFlag,
+					hdCountFlag,
+					hdShowKeyFlag,
+					hdXpubFlag,
+				},
+				Action: func(c *cli.Context) error {
+					return deriveAccounts(c)
+				},
+			},
+		},
+	}
+)
+
+var (
+	vanityPrefixFlag = &cli.StringFlag{
+		Name:  "prefix",
+		Usage: "base58 prefix of the address, starting with T",
+	}
+	vanitySuffixFlag = &cli.StringFlag{
+		Name:  "suffix",
+		Usage: "base58 suffix of the address",
+	}
+	vanityIgnoreCaseFlag = &cli.BoolFlag{
+		Name:  "ignore-case",
+		Usage: "match the prefix and suffix case-insensitively",
+	}
+	vanityCreate2Flag = &cli.StringSliceFlag{
+		Name:  "create2",
+		Usage: "search the salt of the TRON CREATE2 address by `deployer,initcodehash` instead of keys, or give the flag twice: deployer then initcodehash",
+	}
+	vanityWorkersFlag = &cli.IntFlag{
+		Name:  "workers",
+		Value: runtime.NumCPU(),
+		Usage: "number of search goroutines",
+	}
+	vanityShowKeyFlag = &cli.BoolFlag{
+		Name:  "show-key",
+		Usage: "print the private key instead of writing the keystore",
+	}
+	keyVanityCommand = cli.Command{
+		Name:  "vanity",
+		Usage: "Search the private key (or CREATE2 salt) of a TRON address with the prefix and suffix",
+		Flags: []cli.Flag{
+			vanityPrefixFlag,
+			vanitySuffixFlag,
+			vanityIgnoreCaseFlag,
+			vanityCreate2Flag,
+			vanityWorkersFlag,
+			vanityShowKeyFlag,
+			keyDirFlag,
+			keyLightFlag,
+		},
+		Action: func(c *cli.Context) error {
+			return searchVanity(c)
+		},
+	}
+)
+
+func searchVanity(c *cli.Context) error {
+	pattern := &utils.VanityPattern{
+		Prefix:     c.String(vanityPrefixFlag.Name),
+		Suffix:     c.String(vanitySuffixFlag.Name),
+		IgnoreCase: c.Bool(vanityIgnoreCaseFlag.Name),
+	}
+	difficulty, err := pattern.Difficulty()
+	if err != nil {
+		return err
+	}
+	var create2 *utils.VanityCreate2
+	if c.IsSet(vanityCreate2Flag.Name) {
+		args := c.StringSlice(vanityCreate2Flag.Name)
+		if len(args) != 2 {
+			return errors.New("create2 flag should be deployer,initcodehash, or deployer and initcodehash in two flags")
+		}
+		deployer, ok := utils.ToAddress(args[0])
+		if !ok {
+			return errors.New("deployer is not a valid address")
+		}
+		codeHash, ok := utils.FromHex(args[1])
+		if !ok || len(codeHash) != 32 {
+			return errors.New("initcodehash should be 32 bytes in hex")
+		}
+		create2 = &utils.VanityCreate2{Deployer: deployer, InitCodeHash: codeHash}
+	}
+	// ask the password before the search, not after it
+	var password string
+	if create2 == nil && !c.Bool(vanityShowKeyFlag.Name) {
+		if password, err = utils.ReadNewPassword(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("[difficulty]: %.0f attempts expected\n", difficulty)
+	var attempts atomic.Int64
+	bar := utils.NewBar(0, int(min(difficulty, math.MaxInt64/2))).WithSpeed().WithExpected()
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				bar.Reset(int(attempts.Load()))
+			case <-done:
+				return
+			}
+		}
+	}()
+	result, err := utils.SearchVanity(pattern, c.Int(vanityWorkersFlag.Name), create2, &attempts)
+	close(done)
+	<-stopped
+	fmt.Print("\n")
+	if err != nil {
+		return err
+	}
+
+	if create2 != nil {
+		log.NewLog("tron create2", formatAddress(result.Address))
+		log.NewLog("salt", result.Salt)
+		return nil
+	}
+	if c.Bool(vanityShowKeyFlag.Name) {
+		log.NewLog("tron addr", utils.ToBase58(result.Address))
+		log.NewLog("eth addr", common.BytesToAddress(result.Address).String())
+		log.NewLog("private key", fmt.Sprintf("%x", crypto.FromECDSA(result.Key)))
+		return nil
+	}
+	return saveKeystore(c, result.Key, password)
+}
+
+func deriveAccounts(c *cli.Context) error {
+	var coin uint32
+	switch c.String(hdCoinFlag.Name) {
+	case "tron":
+		coin = utils.CoinTRON
+	case "eth":
+		coin = utils.CoinETH
+	default:
+		return errors.New("coin should be tron or eth")
+	}
+	var (
+		account *hdkeychain.ExtendedKey
+		path    string
+		err     error
+	)
+	if c.IsSet(hdXpubFlag.Name) {
+		// the xpub is already the account key, its coin and account can't be changed
+		if c.IsSet(hdCoinFlag.Name) || c.IsSet(hdAccountFlag.Name) {
+			return errors.New("--coin and --account can't be used with --xpub")
+		}
+		if account, err = utils.ParseExtendedKey(c.String(hdXpubFlag.Name)); err != nil {
+			return err
+		}
+		path = "xpub"
+	} else {
+		var mnemonic string
+		if c.IsSet(mnemonicFileFlag.Name) {
+			data, err := readInput(c.String(mnemonicFileFlag.Name))
+			if err != nil {
+				return err
+			}
+			mnemonic = string(data)
+		} else if mnemonic, err = utils.ReadPassword("Mnemonic: "); err != nil {
+			return err
+		}
+		var passphrase string
+		if c.Bool(mnemonicPassphraseFlag.Name) {
+			if passphrase, err = utils.ReadPassword("Passphrase: "); err != nil {
+				return err
+			}
+		}
+		seed, err := utils.MnemonicSeed(strings.Join(strings.Fields(mnemonic), " "), passphrase)
+		if err != nil {
+			return err
+		}
+		if c.Uint(hdAccountFlag.Name) >= hdkeychain.HardenedKeyStart {
+			return fmt.Errorf("account should be less than %d", hdkeychain.HardenedKeyStart)
+		}
+		accountIndex := uint32(c.Uint(hdAccountFlag.Name))
+		if account, err = utils.AccountKey(seed, coin, accountIndex); err != nil {
+			return err
+		}
+		path = fmt.Sprintf("m/44'/%d'/%d'", coin, accountIndex)
+		xpub, err := account.Neuter()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[account]: %s\n", path)
+		fmt.Printf("[xpub]: %s\n", xpub.String())
+	}
+	keys, err := utils.DeriveAddressKeys(account, uint64(c.Uint(hdStartFlag.Name)), uint64(c.Uint(hdCountFlag.Name)))
+	if err != nil {
+		return err
+	}
+	if c.Bool(hdShowKeyFlag.Name) && len(keys) != 0 && keys[0].Private == nil {
+		return errors.New("no private key can be derived from xpub")
+	}
+	for _, key := range keys {
+		addr := crypto.PubkeyToAddress(*key.Public)
+		fmt.Printf("%3d %s/0/%d %s %s", key.Index, path, key.Index, base58.CheckEncode(addr.Bytes(), 0x41), addr.String())
+		if c.Bool(hdShowKeyFlag.Name) {
+			fmt.Printf(" %x", crypto.FromECDSA(key.Private))
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// loadPrivateKey parses the hex key arg if given (it leaks into shell history), otherwise loads
+// the key from the key source flags
+func loadPrivateKey(c *cli.Context, arg string) (*ecdsa.PrivateKey, error) {
+	if len(arg) != 0 {
+		return utils.ParsePrivateKey(arg)
+	}
+	source := &utils.KeySource{
+		Env:          c.String(keyEnvFlag.Name),
+		File:         c.String(keyFileFlag.Name),
+		Keystore:     c.String(keystoreFlag.Name),
+		PasswordFile: c.String(passwordFileFlag.Name),
+	}
+	return source.Load()
+}
+
+// keySourceSet tells if any key source flag is set
+func keySourceSet(c *cli.Context) bool {
+	return c.IsSet(keyEnvFlag.Name) || c.IsSet(keyFileFlag.Name) || c.IsSet(keystoreFlag.Name)
+}
+
+func writeKeystore(c *cli.Context, key *ecdsa.PrivateKey) error {
+	password, err := utils.ReadNewPassword()
+	if err != nil {
+		return err
+	}
+	return saveKeystore(c, key, password)
+}
+
+func saveKeystore(c *cli.Context, key *ecdsa.PrivateKey, password string) error {
+	dir := c.String(keyDirFlag.Name)
+	if len(dir) == 0 {
+		var err error
+		if dir, err = utils.DefaultKeystoreDir(); err != nil {
+			return err
+		}
+	}
+	path, err := utils.WriteKeystore(dir, key, password, c.Bool(keyLightFlag.Name))
+	if err != nil {
+		return err
+	}
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	log.NewLog("eth addr", addr.String())
+	log.NewLog("tron addr", base58.CheckEncode(addr.Bytes(), 0x41))
+	log.NewLog("keystore", path)
+	return nil
+}
@@ -105,17 +105,30 @@ func main() {
 				&abiPackCommand,
 				&abiUnpackCommand,
 				&abi4bytesCommand,
+				&abiSlotCommand,
+				&abiCacheCommand,
+				&abiGuessCommand,
 			},
 		},
 		{
 			Name:  "db",
 			Usage: "Database related commands",
+			Flags: []cli.Flag{
+				dbDataDirFlag,
+				dbEngineFlag,
+			},
 			Subcommands: []*cli.Command{
 				&dbCountCommand,
 				&dbGetCommand,
 				&dbRootCommand,
 				&dbPrintCommand,
 				&dbDiffCommand,
+				&dbBlockCommand,
+				&dbTxCommand,
+				&dbStorageCommand,
+				&dbExportCommand,
+				&dbImportCommand,
+				&dbGrepCommand,
 			},
 		},
 		{
@@ -125,6 +138,13 @@ func main() {
 				&logsCommand,
 			},
 		},
+		{
+			Name:  "evm",
+			Usage: "Local EVM/TVM related commands",
+			Subcommands: []*cli.Command{
+				&evmRunCommand,
+			},
+		},
 		{
 			Name:  "hex",
 			Usage: "Hex related commands",
@@ -134,9 +154,18 @@ func main() {
 				&hexMaxCommand,
 				&hexStrCommand,
 				&hexCodeCommand,
+				&hexCreateCommand,
+				&hexCreate2Command,
 				&hexKeyCommand,
 			},
 		},
+		{
+			Name:  "code",
+			Usage: "Contract code related commands",
+			Subcommands: []*cli.Command{
+				&codeVerifyCommand,
+			},
+		},
 		{
 			Name:  "scan",
 			Usage: "TronScan related commands",
@@ -145,12 +174,27 @@ func main() {
 				&txCommand,
 			},
 		},
+		{
+			Name:  "key",
+			Usage: "Private key and keystore related commands",
+			Subcommands: []*cli.Command{
+				&keyNewCommand,
+				&keyImportCommand,
+				&keyExportCommand,
+				&keyMnemonicCommand,
+				&keyVanityCommand,
+			},
+		},
 		{
 			Name:  "tx",
 			Usage: "Transaction related commands",
 			Subcommands: []*cli.Command{
 				&signCommand,
 				&recoverCommand,
+				&sigCommand,
+				&signMsgCommand,
+				&verifyMsgCommand,
+				&typedCommand,
 			},
 		},
 	}
@@ -14,8 +14,11 @@ import (
 )
 
 const (
-	Endpoint    = "https://%s.trongrid.io/"
-	TriggerPath = "wallet/triggerconstantcontract"
+	Endpoint     = "https://%s.trongrid.io/"
+	TriggerPath  = "wallet/triggerconstantcontract"
+	ContractPath = "wallet/getcontract"
+	InfoPath     = "wallet/getcontractinfo"
+	JsonRpcPath  = "jsonrpc"
 )
 
 var appClient = &http.Client{
@@ -87,6 +90,101 @@ func Trigger(net, addr, from, selector, params string) *TriggerResponse {
 	return nil
 }
 
+type JsonRpcRequest struct {
+	JsonRpc string        `json:"jsonrpc"`
+	Id      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type JsonRpcResponse struct {
+	Result json.RawMessage
+	Error  *struct {
+		Code    int
+		Message string
+	}
+}
+
+// GetStorageAt reads the storage slot by `eth_getStorageAt` of the TRON JSON-RPC, addr is the 20 bytes address in hex
+func GetStorageAt(net, addr string, slot []byte) ([]byte, error) {
+	req := &JsonRpcRequest{
+		JsonRpc: "2.0",
+		Id:      1,
+		Method:  "eth_getStorageAt",
+		Params:  []interface{}{addr, fmt.Sprintf("0x%x", slot), "latest"},
+	}
+	var rsp JsonRpcResponse
+	if err := HighPost(fmt.Sprintf(Endpoint, net)+JsonRpcPath, req, &rsp); err != nil {
+		return nil, err
+	}
+	if rsp.Error != nil {
+		return nil, fmt.Errorf("eth_getStorageAt failed: %s", rsp.Error.Message)
+	}
+	var value string
+	if err := json.Unmarshal(rsp.Result, &value); err != nil {
+		return nil, err
+	}
+	return common.FromHex(value), nil
+}
+
+// GetContractCode reads the `bytecode` by `wallet/getcontract` of TRON, it's the creation code and
+// the constructor args sent by the deploy transaction, addr is in base58. The contracts created by
+// other contracts have no bytecode, their runtime code is returned instead, creation tells which one.
+func GetContractCode(net, addr string) (code []byte, creation bool, err error) {
+	req := map[string]interface{}{"value": addr, "visible": true}
+	var rsp struct {
+		Bytecode string `json:"bytecode"`
+	}
+	if err := HighPost(fmt.Sprintf(Endpoint, net)+ContractPath, req, &rsp); err != nil {
+		return nil, false, err
+	}
+	if len(rsp.Bytecode) != 0 {
+		return common.FromHex(rsp.Bytecode), true, nil
+	}
+	code, err = GetRuntimeCode(net, addr)
+	return code, false, err
+}
+
+// GetRuntimeCode reads the `runtimecode` by `wallet/getcontractinfo` of TRON, addr is in base58
+func GetRuntimeCode(net, addr string) ([]byte, error) {
+	req := map[string]interface{}{"value": addr, "visible": true}
+	var info struct {
+		RuntimeCode string `json:"runtimecode"`
+	}
+	if err := HighPost(fmt.Sprintf(Endpoint, net)+InfoPath, req, &info); err != nil {
+		return nil, err
+	}
+	if len(info.RuntimeCode) == 0 {
+		return nil, fmt.Errorf("no contract found at %s", addr)
+	}
+	return common.FromHex(info.RuntimeCode), nil
+}
+
+// GetCode reads the runtime code by `eth_getCode` of the JSON-RPC endpoint url, addr is in hex
+func GetCode(url, addr string) ([]byte, error) {
+	req := &JsonRpcRequest{
+		JsonRpc: "2.0",
+		Id:      1,
+		Method:  "eth_getCode",
+		Params:  []interface{}{addr, "latest"},
+	}
+	var rsp JsonRpcResponse
+	if err := HighPost(url, req, &rsp); err != nil {
+		return nil, err
+	}
+	if rsp.Error != nil {
+		return nil, fmt.Errorf("eth_getCode failed: %s", rsp.Error.Message)
+	}
+	var value string
+	if err := json.Unmarshal(rsp.Result, &value); err != nil {
+		return nil, err
+	}
+	if code := common.FromHex(value); len(code) != 0 {
+		return code, nil
+	}
+	return nil, fmt.Errorf("no contract found at %s", addr)
+}
+
 type RspEtherFace struct {
 	Items []struct {
 		Text string `json:"text"`
@@ -133,16 +133,7 @@ var (
 					if err != nil {
 						return err
 					}
-					if len(data) == 0 {
-						fmt.Println("[No return data]")
-					} else {
-						fmt.Println("[Return data]:")
-						fmt.Println("  - In HEX: " + hexutils.BytesToHex(data))
-						if len(data) == 32 {
-							fmt.Println("  - In INT: " + big.NewInt(0).SetBytes(data).String())
-						}
-						fmt.Println("  - In ASCII: " + utils.ToReadableASCII(data))
-					}
+					printReturnData(data)
 				}
 			}
 
@@ -173,25 +164,7 @@ var (
 			// we get the method signature, so try to abi.decode
 			if len(method) != 0 {
 				fmt.Println("[Method]: " + method)
-				result := strings.FieldsFunc(method, func(r rune) bool {
-					if r == '(' || r == ')' || r == ',' {
-						return true
-					}
-					return false
-				})
-				args := abi.Arguments{}
-				for _, param := range result[1:] {
-					if strings.ContainsAny(param, " ") {
-						param = strings.Split(param, " ")[0]
-					}
-					solType, _ := abi.NewType(param, "", nil)
-					args = append(args, abi.Argument{Type: solType})
-				}
-				if res, err := args.UnpackValues(callData[4:]); err == nil {
-					for i, r := range res {
-						printSol(r, &args[i].Type, "Arg", i, 1)
-					}
-				}
+				printMethodArgs(method, callData)
 			} else if len(scanTxInfo.ContractData.Data) >= 8 {
 				fmt.Println("[Selector]: " + scanTxInfo.ContractData.Data[:8])
 				fmt.Println("[DataWord]:")
@@ -203,6 +176,43 @@ var (
 	}
 )
 
+func printReturnData(data []byte) {
+	if len(data) == 0 {
+		fmt.Println("[No return data]")
+	} else {
+		fmt.Println("[Return data]:")
+		fmt.Println("  - In HEX: " + hexutils.BytesToHex(data))
+		if len(data) == 32 {
+			fmt.Println("  - In INT: " + big.NewInt(0).SetBytes(data).String())
+		}
+		fmt.Println("  - In ASCII: " + utils.ToReadableASCII(data))
+	}
+}
+
+// printMethodArgs abi.decode the calldata (selector included) with the method signature
+func printMethodArgs(method string, callData []byte) {
+	if len(callData) < 4 {
+		return
+	}
+	result := strings.FieldsFunc(method, func(r rune) bool {
+		if r == '(' || r == ')' || r == ',' {
+			return true
+		}
+		return false
+	})
+	args := abi.Arguments{}
+	for _, param := range result[1:] {
+		if strings.ContainsAny(param, " ") {
+			param = strings.Split(param, " ")[0]
+		}
+		solType, _ := abi.NewType(param, "", nil)
+		args = append(args, abi.Argument{Type: solType})
+	}
+	if res, err := args.UnpackValues(callData[4:]); err == nil {
+		newArgPrinter(method).printArgs(args, res, "Arg", 1)
+	}
+}
+
 type Txs struct {
 	Total int
 	Data  []Tx
@@ -0,0 +1,473 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// Block is the decoded `block` store entry (protocol.Block), only the fields we display are kept.
+type Block struct {
+	Header       BlockHeader
+	Transactions []*Transaction
+}
+
+type BlockHeader struct {
+	Timestamp        int64
+	TxTrieRoot       []byte
+	ParentHash       []byte
+	Number           int64
+	WitnessAddress   []byte
+	Version          int32
+	AccountStateRoot []byte
+	WitnessSignature []byte
+	raw              []byte
+}
+
+// ID is the block id used as key of the `block` store, block number followed by the hash tail.
+func (h *BlockHeader) ID() []byte {
+	id := sha256.Sum256(h.raw)
+	binary.BigEndian.PutUint64(id[:8], uint64(h.Number))
+	return id[:]
+}
+
+type Transaction struct {
+	RefBlockBytes []byte
+	RefBlockHash  []byte
+	Expiration    int64
+	Timestamp     int64
+	FeeLimit      int64
+	Data          []byte
+	Contracts     []Contract
+	Signatures    [][]byte
+	ContractRets  []int32
+	raw           []byte
+}
+
+// ID is the txid, sha256 of the raw data.
+func (tx *Transaction) ID() []byte {
+	id := sha256.Sum256(tx.raw)
+	return id[:]
+}
+
+// ContractRet is the result code of the first contract (SUCCESS, REVERT, ...).
+func (tx *Transaction) ContractRet() string {
+	if len(tx.ContractRets) == 0 {
+		return contractResultNames[0]
+	}
+	return ContractResultName(tx.ContractRets[0])
+}
+
+type Contract struct {
+	Type         int32
+	TypeURL      string
+	Parameter    []byte
+	PermissionID int32
+}
+
+func (c *Contract) TypeName() string {
+	if name, ok := contractTypeNames[c.Type]; ok {
+		return name
+	}
+	return fmt.Sprintf("UnknownContract(%d)", c.Type)
+}
+
+// Owner is the `owner_address`, which is the first field of almost every system contract.
+func (c *Contract) Owner() []byte {
+	if c.Type == TransferAssetContract {
+		return c.bytesField(2)
+	}
+	return c.bytesField(1)
+}
+
+// To is the target of the contract, `to_address` for TransferContract/TransferAssetContract
+// and `contract_address` for TriggerSmartContract.
+func (c *Contract) To() []byte {
+	switch c.Type {
+	case TransferContract, TriggerSmartContract:
+		return c.bytesField(2)
+	case TransferAssetContract:
+		return c.bytesField(3)
+	}
+	return nil
+}
+
+// CallValue is `amount` of TransferContract or `call_value` of TriggerSmartContract.
+func (c *Contract) CallValue() int64 {
+	switch c.Type {
+	case TransferContract, TriggerSmartContract:
+		return c.intField(3)
+	case TransferAssetContract:
+		return c.intField(4)
+	}
+	return 0
+}
+
+// CallData is the `data` of TriggerSmartContract.
+func (c *Contract) CallData() []byte {
+	if c.Type == TriggerSmartContract {
+		return c.bytesField(4)
+	}
+	return nil
+}
+
+func (c *Contract) bytesField(num int) []byte {
+	fields, _ := decodeProto(c.Parameter)
+	for _, f := range fields {
+		if f.Num == num && f.Wire == wireLen {
+			return f.Bytes
+		}
+	}
+	return nil
+}
+
+func (c *Contract) intField(num int) int64 {
+	fields, _ := decodeProto(c.Parameter)
+	for _, f := range fields {
+		if f.Num == num && f.Wire == wireVarint {
+			return int64(f.Varint)
+		}
+	}
+	return 0
+}
+
+type TransactionInfo struct {
+	ID             []byte
+	Fee            int64
+	BlockNumber    int64
+	BlockTimestamp int64
+	ContractResult [][]byte
+	ContractAddr   []byte
+	Receipt        ResourceReceipt
+	Logs           []TxLog
+	Failed         bool
+	ResMessage     []byte
+}
+
+type ResourceReceipt struct {
+	EnergyUsage       int64
+	EnergyFee         int64
+	OriginEnergyUsage int64
+	EnergyUsageTotal  int64
+	NetUsage          int64
+	NetFee            int64
+	Result            int32
+}
+
+type TxLog struct {
+	Address []byte
+	Topics  [][]byte
+	Data    []byte
+}
+
+func DecodeBlock(data []byte) (*Block, error) {
+	fields, err := decodeProto(data)
+	if err != nil {
+		return nil, err
+	}
+	block := new(Block)
+	for _, f := range fields {
+		switch f.Num {
+		case 1:
+			tx, err := DecodeTransaction(f.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			block.Transactions = append(block.Transactions, tx)
+		case 2:
+			if err := decodeBlockHeader(f.Bytes, &block.Header); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return block, nil
+}
+
+func decodeBlockHeader(data []byte, header *BlockHeader) error {
+	fields, err := decodeProto(data)
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		switch f.Num {
+		case 1:
+			header.raw = f.Bytes
+		case 2:
+			header.WitnessSignature = f.Bytes
+		}
+	}
+	raw, err := decodeProto(header.raw)
+	if err != nil {
+		return err
+	}
+	for _, f := range raw {
+		switch f.Num {
+		case 1:
+			header.Timestamp = int64(f.Varint)
+		case 2:
+			header.TxTrieRoot = f.Bytes
+		case 3:
+			header.ParentHash = f.Bytes
+		case 7:
+			header.Number = int64(f.Varint)
+		case 9:
+			header.WitnessAddress = f.Bytes
+		case 10:
+			header.Version = int32(f.Varint)
+		case 11:
+			header.AccountStateRoot = f.Bytes
+		}
+	}
+	return nil
+}
+
+func DecodeTransaction(data []byte) (*Transaction, error) {
+	fields, err := decodeProto(data)
+	if err != nil {
+		return nil, err
+	}
+	tx := new(Transaction)
+	for _, f := range fields {
+		switch f.Num {
+		case 1:
+			tx.raw = f.Bytes
+		case 2:
+			tx.Signatures = append(tx.Signatures, f.Bytes)
+		case 5:
+			ret, err := decodeProto(f.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			var contractRet int32
+			for _, r := range ret {
+				if r.Num == 3 {
+					contractRet = int32(r.Varint)
+				}
+			}
+			tx.ContractRets = append(tx.ContractRets, contractRet)
+		}
+	}
+	raw, err := decodeProto(tx.raw)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range raw {
+		switch f.Num {
+		case 1:
+			tx.RefBlockBytes = f.Bytes
+		case 4:
+			tx.RefBlockHash = f.Bytes
+		case 8:
+			tx.Expiration = int64(f.Varint)
+		case 10:
+			tx.Data = f.Bytes
+		case 11:
+			contract, err := decodeContract(f.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			tx.Contracts = append(tx.Contracts, contract)
+		case 14:
+			tx.Timestamp = int64(f.Varint)
+		case 18:
+			tx.FeeLimit = int64(f.Varint)
+		}
+	}
+	return tx, nil
+}
+
+func decodeContract(data []byte) (Contract, error) {
+	var contract Contract
+	fields, err := decodeProto(data)
+	if err != nil {
+		return contract, err
+	}
+	for _, f := range fields {
+		switch f.Num {
+		case 1:
+			contract.Type = int32(f.Varint)
+		case 2:
+			// google.protobuf.Any
+			anyFields, err := decodeProto(f.Bytes)
+			if err != nil {
+				return contract, err
+			}
+			for _, a := range anyFields {
+				switch a.Num {
+				case 1:
+					contract.TypeURL = string(a.Bytes)
+				case 2:
+					contract.Parameter = a.Bytes
+				}
+			}
+		case 5:
+			contract.PermissionID = int32(f.Varint)
+		}
+	}
+	return contract, nil
+}
+
+func DecodeTransactionInfo(data []byte) (*TransactionInfo, error) {
+	fields, err := decodeProto(data)
+	if err != nil {
+		return nil, err
+	}
+	info := new(TransactionInfo)
+	for _, f := range fields {
+		switch f.Num {
+		case 1:
+			info.ID = f.Bytes
+		case 2:
+			info.Fee = int64(f.Varint)
+		case 3:
+			info.BlockNumber = int64(f.Varint)
+		case 4:
+			info.BlockTimestamp = int64(f.Varint)
+		case 5:
+			info.ContractResult = append(info.ContractResult, f.Bytes)
+		case 6:
+			info.ContractAddr = f.Bytes
+		case 7:
+			receipt, err := decodeProto(f.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range receipt {
+				switch r.Num {
+				case 1:
+					info.Receipt.EnergyUsage = int64(r.Varint)
+				case 2:
+					info.Receipt.EnergyFee = int64(r.Varint)
+				case 3:
+					info.Receipt.OriginEnergyUsage = int64(r.Varint)
+				case 4:
+					info.Receipt.EnergyUsageTotal = int64(r.Varint)
+				case 5:
+					info.Receipt.NetUsage = int64(r.Varint)
+				case 6:
+					info.Receipt.NetFee = int64(r.Varint)
+				case 7:
+					info.Receipt.Result = int32(r.Varint)
+				}
+			}
+		case 8:
+			log, err := decodeProto(f.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			var txLog TxLog
+			for _, l := range log {
+				switch l.Num {
+				case 1:
+					txLog.Address = l.Bytes
+				case 2:
+					txLog.Topics = append(txLog.Topics, l.Bytes)
+				case 3:
+					txLog.Data = l.Bytes
+				}
+			}
+			info.Logs = append(info.Logs, txLog)
+		case 9:
+			info.Failed = f.Varint != 0
+		case 10:
+			info.ResMessage = f.Bytes
+		}
+	}
+	return info, nil
+}
+
+// DecodeTransactionRet decodes the `transactionRetStore` entry, which keeps all the
+// TransactionInfo of a block.
+func DecodeTransactionRet(data []byte) ([]*TransactionInfo, error) {
+	fields, err := decodeProto(data)
+	if err != nil {
+		return nil, err
+	}
+	var infos []*TransactionInfo
+	for _, f := range fields {
+		if f.Num == 3 {
+			info, err := DecodeTransactionInfo(f.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+const (
+	TransferContract      = 1
+	TransferAssetContract = 2
+	TriggerSmartContract  = 31
+)
+
+var contractTypeNames = map[int32]string{
+	0:  "AccountCreateContract",
+	1:  "TransferContract",
+	2:  "TransferAssetContract",
+	3:  "VoteAssetContract",
+	4:  "VoteWitnessContract",
+	5:  "WitnessCreateContract",
+	6:  "AssetIssueContract",
+	8:  "WitnessUpdateContract",
+	9:  "ParticipateAssetIssueContract",
+	10: "AccountUpdateContract",
+	11: "FreezeBalanceContract",
+	12: "UnfreezeBalanceContract",
+	13: "WithdrawBalanceContract",
+	14: "UnfreezeAssetContract",
+	15: "UpdateAssetContract",
+	16: "ProposalCreateContract",
+	17: "ProposalApproveContract",
+	18: "ProposalDeleteContract",
+	19: "SetAccountIdContract",
+	20: "CustomContract",
+	30: "CreateSmartContract",
+	31: "TriggerSmartContract",
+	32: "GetContract",
+	33: "UpdateSettingContract",
+	41: "ExchangeCreateContract",
+	42: "ExchangeInjectContract",
+	43: "ExchangeWithdrawContract",
+	44: "ExchangeTransactionContract",
+	45: "UpdateEnergyLimitContract",
+	46: "AccountPermissionUpdateContract",
+	48: "ClearABIContract",
+	49: "UpdateBrokerageContract",
+	51: "ShieldedTransferContract",
+	52: "MarketSellAssetContract",
+	53: "MarketCancelOrderContract",
+	54: "FreezeBalanceV2Contract",
+	55: "UnfreezeBalanceV2Contract",
+	56: "WithdrawExpireUnfreezeContract",
+	57: "DelegateResourceContract",
+	58: "UnDelegateResourceContract",
+	59: "CancelAllUnfreezeV2Contract",
+}
+
+var contractResultNames = []string{
+	"DEFAULT",
+	"SUCCESS",
+	"REVERT",
+	"BAD_JUMP_DESTINATION",
+	"OUT_OF_MEMORY",
+	"PRECOMPILED_CONTRACT",
+	"STACK_TOO_SMALL",
+	"STACK_TOO_LARGE",
+	"ILLEGAL_OPERATION",
+	"STACK_OVERFLOW",
+	"OUT_OF_ENERGY",
+	"OUT_OF_TIME",
+	"JVM_STACK_OVER_FLOW",
+	"UNKNOWN",
+	"TRANSFER_FAILED",
+	"INVALID_CODE",
+}
+
+func ContractResultName(code int32) string {
+	if code >= 0 && int(code) < len(contractResultNames) {
+		return contractResultNames[code]
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", code)
+}
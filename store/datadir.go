@@ -0,0 +1,134 @@
+package store
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	DatabaseDir = "database"
+	EngineFile  = "engine.properties"
+	EngineKey   = "ENGINE"
+
+	EngineLevelDB = "LEVELDB"
+	EngineRocksDB = "ROCKSDB"
+)
+
+// DataDir is the `database` directory of a java-tron node, every sub dir is a single store
+// like `account`, `block`, `block-index`, `properties` or `checkpoint`.
+type DataDir struct {
+	Root string
+}
+
+// OpenDataDir accepts either the node output directory (which contains `database`) or the
+// `database` directory itself.
+func OpenDataDir(path string) (*DataDir, error) {
+	root := path
+	if isDir(filepath.Join(path, DatabaseDir)) {
+		root = filepath.Join(path, DatabaseDir)
+	}
+	if !isDir(root) {
+		return nil, fmt.Errorf("datadir %s is not a directory", path)
+	}
+	// a real database dir always has the properties store
+	if !isDir(filepath.Join(root, "properties")) {
+		return nil, fmt.Errorf("%s does not look like a java-tron database dir (no properties store)", root)
+	}
+	return &DataDir{Root: root}, nil
+}
+
+// StorePath returns the path of the given store name. A name containing a path separator
+// is treated as a path and returned as is.
+func (d *DataDir) StorePath(name string) (string, error) {
+	if strings.ContainsRune(name, filepath.Separator) {
+		return name, nil
+	}
+	path := filepath.Join(d.Root, name)
+	if !isDir(path) {
+		return "", fmt.Errorf("store `%s` not found in %s", name, d.Root)
+	}
+	// checkpoint v2 keeps a snapshot db per flush, named by timestamp, use the latest one
+	if !isStore(path) {
+		if subs := subDirs(path); len(subs) != 0 {
+			return filepath.Join(path, subs[len(subs)-1]), nil
+		}
+	}
+	return path, nil
+}
+
+// Stores lists all store names in the data dir.
+func (d *DataDir) Stores() []string {
+	return subDirs(d.Root)
+}
+
+// ReadEngine reads the engine of the store from its engine.properties file,
+// stores created by old nodes have no such file and are always LevelDB.
+func ReadEngine(storePath string) (string, error) {
+	file, err := os.Open(filepath.Join(storePath, EngineFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return EngineLevelDB, nil
+	} else if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, "="); ok && strings.TrimSpace(k) == EngineKey {
+			return strings.ToUpper(strings.TrimSpace(v)), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return EngineLevelDB, nil
+}
+
+// CheckEngine makes sure the store at path is in one of the supported engines.
+func CheckEngine(storePath string, supported ...string) (string, error) {
+	engine, err := ReadEngine(storePath)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range supported {
+		if engine == s {
+			return engine, nil
+		}
+	}
+	return "", fmt.Errorf("store %s uses %s engine, supported: %s", storePath, engine, strings.Join(supported, ","))
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// both LevelDB and RocksDB keep a CURRENT file in the store dir
+func isStore(path string) bool {
+	_, err := os.Stat(filepath.Join(path, "CURRENT"))
+	return err == nil
+}
+
+func subDirs(path string) []string {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrNotFound is returned by DB.Get for missing keys, whatever the engine is.
+var ErrNotFound = errors.New("not found")
+
+// DB is the read-only view of a single store, implemented by each engine.
+type DB interface {
+	Get(key []byte) ([]byte, error)
+	// NewIterator iterates the keys in the range in order, nil range means the whole db.
+	NewIterator(slice *Range) Iterator
+	Close() error
+}
+
+// Iterator follows goleveldb: call Next before the first Key, Key and Value are only
+// valid until the next call of Next.
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Release()
+	Error() error
+}
+
+// Range is the key range [Start, Limit), nil Start or Limit means unbounded.
+type Range struct {
+	Start []byte
+	Limit []byte
+}
+
+// BytesPrefix returns the range of all keys with the prefix.
+func BytesPrefix(prefix []byte) *Range {
+	var limit []byte
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if c := prefix[i]; c < 0xff {
+			limit = make([]byte, i+1)
+			copy(limit, prefix)
+			limit[i] = c + 1
+			break
+		}
+	}
+	return &Range{Start: prefix, Limit: limit}
+}
+
+var engines = map[string]func(path string) (DB, error){}
+
+// RegisterEngine makes the engine available to Open, engines register themselves in init.
+func RegisterEngine(name string, open func(path string) (DB, error)) {
+	engines[name] = open
+}
+
+func Engines() []string {
+	var names []string
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open opens the store read-only, empty engine means the one in engine.properties of the store.
+func Open(path, engine string) (DB, error) {
+	if len(engine) == 0 {
+		var err error
+		if engine, err = ReadEngine(path); err != nil {
+			return nil, err
+		}
+	}
+	engine = strings.ToUpper(engine)
+	open, ok := engines[engine]
+	if !ok {
+		if engine == EngineRocksDB {
+			return nil, fmt.Errorf("store %s uses %s engine, rebuild with `-tags rocksdb` to support it", path, engine)
+		}
+		return nil, fmt.Errorf("store %s uses %s engine, supported: %s", path, engine, strings.Join(Engines(), ","))
+	}
+	return open(path)
+}
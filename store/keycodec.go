@@ -0,0 +1,275 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// KeyCodec converts db keys between the input text and bytes.
+type KeyCodec interface {
+	Decode(s string) ([]byte, error)
+	Encode(key []byte) string
+}
+
+var keyCodecs = map[string]KeyCodec{
+	"hex":       hexCodec{},
+	"base58":    base58Codec{},
+	"utf8":      utf8Codec{},
+	"int64":     intCodec{size: 8, signed: true},
+	"uint64":    intCodec{size: 8},
+	"composite": compositeCodec{},
+}
+
+// RegisterKeyCodec makes the codec usable by name with `--key-format`.
+func RegisterKeyCodec(name string, codec KeyCodec) {
+	keyCodecs[name] = codec
+}
+
+func KeyFormats() []string {
+	var names []string
+	for name := range keyCodecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetKeyCodec returns the codec of the format, empty format means auto-detection.
+func GetKeyCodec(format string) (KeyCodec, error) {
+	if len(format) == 0 {
+		return autoCodec{}, nil
+	}
+	if codec, ok := keyCodecs[strings.ToLower(format)]; ok {
+		return codec, nil
+	}
+	return nil, fmt.Errorf("unknown key format `%s`, supported: %s", format, strings.Join(KeyFormats(), "|"))
+}
+
+// DecodeKey decodes the key text with the given format.
+func DecodeKey(format, s string) ([]byte, error) {
+	codec, err := GetKeyCodec(format)
+	if err != nil {
+		return nil, err
+	}
+	return codec.Decode(s)
+}
+
+type hexCodec struct{}
+
+func (hexCodec) Decode(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	key, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex key %q: %w", s, err)
+	}
+	return key, nil
+}
+
+func (hexCodec) Encode(key []byte) string {
+	return hex.EncodeToString(key)
+}
+
+// base58Codec keeps the version byte, so TRON addresses decode to the 21 bytes form used in stores.
+type base58Codec struct{}
+
+func (base58Codec) Decode(s string) ([]byte, error) {
+	payload, version, err := base58.CheckDecode(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base58 key %q: %w", s, err)
+	}
+	return append([]byte{version}, payload...), nil
+}
+
+func (base58Codec) Encode(key []byte) string {
+	if len(key) == 0 {
+		return ""
+	}
+	return base58.CheckEncode(key[1:], key[0])
+}
+
+type utf8Codec struct{}
+
+func (utf8Codec) Decode(s string) ([]byte, error) {
+	return []byte(s), nil
+}
+
+func (utf8Codec) Encode(key []byte) string {
+	return string(key)
+}
+
+// intCodec is the big-endian fixed size integer, like the keys of block-index or transactionRetStore.
+type intCodec struct {
+	size   int
+	signed bool
+}
+
+func (c intCodec) Decode(s string) ([]byte, error) {
+	key := make([]byte, 8)
+	if c.signed {
+		num, err := strconv.ParseInt(s, 0, c.size*8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid int%d key %q", c.size*8, s)
+		}
+		binary.BigEndian.PutUint64(key, uint64(num))
+	} else {
+		num, err := strconv.ParseUint(s, 0, c.size*8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uint%d key %q", c.size*8, s)
+		}
+		binary.BigEndian.PutUint64(key, num)
+	}
+	return key[8-c.size:], nil
+}
+
+func (c intCodec) Encode(key []byte) string {
+	if len(key) != c.size {
+		return hex.EncodeToString(key)
+	}
+	buf := make([]byte, 8)
+	copy(buf[8-c.size:], key)
+	num := binary.BigEndian.Uint64(buf)
+	if c.signed {
+		return strconv.FormatInt(int64(num)<<(64-c.size*8)>>(64-c.size*8), 10)
+	}
+	return strconv.FormatUint(num, 10)
+}
+
+// compositeCodec joins typed segments with `+`, e.g. `addr:T...+u64:5`. A segment only ends at the
+// `+` followed by a known type, so `utf8:a+b+u64:5` is the string `a+b` and the uint64 5.
+type compositeCodec struct{}
+
+var segmentCodecs = map[string]KeyCodec{
+	"addr": addrCodec{},
+	"hex":  hexCodec{},
+	"b58":  base58Codec{},
+	"utf8": utf8Codec{},
+	"str":  utf8Codec{},
+	"i64":  intCodec{size: 8, signed: true},
+	"u64":  intCodec{size: 8},
+	"i32":  intCodec{size: 4, signed: true},
+	"u32":  intCodec{size: 4},
+	"u8":   intCodec{size: 1},
+}
+
+func (compositeCodec) Decode(s string) ([]byte, error) {
+	var key []byte
+	for _, segment := range splitSegments(s) {
+		ty, value, ok := strings.Cut(strings.TrimSpace(segment), ":")
+		codec, known := segmentCodecs[ty]
+		if !ok || !known {
+			return nil, fmt.Errorf("invalid key segment %q, should be like addr:T...|hex:..|b58:..|utf8:..|i64:..|u64:..|i32:..|u32:..|u8:..", segment)
+		}
+		part, err := codec.Decode(value)
+		if err != nil {
+			return nil, err
+		}
+		key = append(key, part...)
+	}
+	return key, nil
+}
+
+// splitSegments splits the composite key at each `+` followed by a typed segment.
+func splitSegments(s string) []string {
+	var segments []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] != '+' {
+			continue
+		}
+		if ty, _, ok := strings.Cut(s[i+1:], ":"); ok {
+			if _, known := segmentCodecs[strings.TrimSpace(ty)]; known {
+				segments = append(segments, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(segments, s[start:])
+}
+
+func (compositeCodec) Encode(key []byte) string {
+	return hex.EncodeToString(key)
+}
+
+// addrCodec accepts base58 or hex address and always outputs the 21 bytes form.
+type addrCodec struct{}
+
+func (addrCodec) Decode(s string) ([]byte, error) {
+	if len(s) == 34 && s[0] == 'T' {
+		return base58Codec{}.Decode(s)
+	}
+	addr, err := hexutil.Decode(s)
+	if err != nil {
+		addr, err = hex.DecodeString(s)
+	}
+	if err != nil || (len(addr) != 20 && len(addr) != 21) {
+		return nil, fmt.Errorf("invalid address %q", s)
+	}
+	if len(addr) == 20 {
+		addr = append([]byte{0x41}, addr...)
+	}
+	return addr, nil
+}
+
+func (addrCodec) Encode(key []byte) string {
+	return base58Codec{}.Encode(key)
+}
+
+// autoCodec guesses the format: 0x-prefixed hex, base58 TRON address, typed composite segments,
+// otherwise the raw utf8 string. All-digit keys stay utf8 since some stores have digit string keys,
+// see NumericKey for the uint64 form.
+type autoCodec struct{}
+
+func (autoCodec) Decode(s string) ([]byte, error) {
+	if len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		if key, err := (hexCodec{}).Decode(s); err == nil {
+			return key, nil
+		}
+	}
+	if len(s) == 34 && s[0] == 'T' {
+		if key, err := (base58Codec{}).Decode(s); err == nil {
+			return key, nil
+		}
+	}
+	if ty, _, ok := strings.Cut(s, ":"); ok {
+		if _, known := segmentCodecs[ty]; known {
+			if key, err := (compositeCodec{}).Decode(s); err == nil {
+				return key, nil
+			}
+		}
+	}
+	return []byte(s), nil
+}
+
+func (autoCodec) Encode(key []byte) string {
+	return hex.EncodeToString(key)
+}
+
+// NumericKey returns the 8 bytes big-endian form of an all-digit key, like the block numbers, the
+// second guess of the auto-detection after the utf8 form.
+func NumericKey(s string) ([]byte, bool) {
+	if !isDigits(s) {
+		return nil, false
+	}
+	// decimal only, a leading zero isn't octal here
+	num, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	return binary.BigEndian.AppendUint64(nil, num), true
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return len(s) != 0
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"errors"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+func init() {
+	RegisterEngine(EngineLevelDB, openLevelDB)
+}
+
+type levelDB struct {
+	db *leveldb.DB
+}
+
+func openLevelDB(path string) (DB, error) {
+	db, err := leveldb.OpenFile(path, &opt.Options{
+		ErrorIfMissing: true,
+		ReadOnly:       true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &levelDB{db: db}, nil
+}
+
+func (l *levelDB) Get(key []byte) ([]byte, error) {
+	value, err := l.db.Get(key, nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (l *levelDB) NewIterator(slice *Range) Iterator {
+	if slice == nil {
+		return l.db.NewIterator(nil, nil)
+	}
+	return l.db.NewIterator(&util.Range{Start: slice.Start, Limit: slice.Limit}, nil)
+}
+
+func (l *levelDB) Close() error {
+	return l.db.Close()
+}
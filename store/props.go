@@ -0,0 +1,164 @@
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	LatestBlockNumKey  = "latest_block_header_number"
+	LatestBlockHashKey = "latest_block_header_hash"
+)
+
+type PropKind int
+
+const (
+	PropLong PropKind = iota
+	PropBool
+	PropTime
+	PropHash
+	PropString
+)
+
+type Property struct {
+	Key  string
+	Kind PropKind
+}
+
+// Decode renders the value the way DynamicPropertiesStore stores it.
+func (p Property) Decode(value []byte) string {
+	switch p.Kind {
+	case PropLong, PropBool, PropTime:
+		if len(value) != 8 {
+			break
+		}
+		num := int64(binary.BigEndian.Uint64(value))
+		switch p.Kind {
+		case PropBool:
+			return fmt.Sprintf("%d (%t)", num, num != 0)
+		case PropTime:
+			return fmt.Sprintf("%d (%s)", num, time.UnixMilli(num).Format("2006-01-02 15:04:05"))
+		default:
+			return strconv.FormatInt(num, 10)
+		}
+	case PropString:
+		return string(value)
+	}
+	return fmt.Sprintf("0x%x", value)
+}
+
+// DynamicProperties are the well-known keys of the java-tron `properties` store.
+var DynamicProperties = []Property{
+	{"latest_block_header_timestamp", PropTime},
+	{LatestBlockNumKey, PropLong},
+	{LatestBlockHashKey, PropHash},
+	{"state_flag", PropLong},
+	{"LATEST_SOLIDIFIED_BLOCK_NUM", PropLong},
+	{"LATEST_PROPOSAL_NUM", PropLong},
+	{"LATEST_EXCHANGE_NUM", PropLong},
+	{"BLOCK_FILLED_SLOTS", PropString},
+	{"BLOCK_FILLED_SLOTS_INDEX", PropLong},
+	{"BLOCK_FILLED_SLOTS_NUMBER", PropLong},
+	{"NEXT_MAINTENANCE_TIME", PropTime},
+	{"MAINTENANCE_TIME_INTERVAL", PropLong},
+	{"MAINTENANCE_SKIP_SLOTS", PropLong},
+	{"SINGLE_REPEAT", PropLong},
+	{"CURRENT_CYCLE_NUMBER", PropLong},
+	{"CHANGE_DELEGATION", PropBool},
+	{"NEW_REWARD_ALGORITHM_EFFECTIVE_CYCLE", PropLong},
+	{"ACCOUNT_UPGRADE_COST", PropLong},
+	{"WITNESS_PAY_PER_BLOCK", PropLong},
+	{"WITNESS_127_PAY_PER_BLOCK", PropLong},
+	{"WITNESS_STANDBY_ALLOWANCE", PropLong},
+	{"WITNESS_ALLOWANCE_FROZEN_TIME", PropLong},
+	{"ONE_DAY_NET_LIMIT", PropLong},
+	{"PUBLIC_NET_USAGE", PropLong},
+	{"PUBLIC_NET_LIMIT", PropLong},
+	{"PUBLIC_NET_TIME", PropLong},
+	{"FREE_NET_LIMIT", PropLong},
+	{"TOTAL_NET_WEIGHT", PropLong},
+	{"TOTAL_NET_LIMIT", PropLong},
+	{"TOTAL_ENERGY_WEIGHT", PropLong},
+	{"TOTAL_TRON_POWER_WEIGHT", PropLong},
+	{"TOTAL_ENERGY_LIMIT", PropLong},
+	{"TOTAL_ENERGY_CURRENT_LIMIT", PropLong},
+	{"TOTAL_ENERGY_AVERAGE_USAGE", PropLong},
+	{"TOTAL_ENERGY_AVERAGE_TIME", PropLong},
+	{"TOTAL_ENERGY_TARGET_LIMIT", PropLong},
+	{"ADAPTIVE_RESOURCE_LIMIT_MULTIPLIER", PropLong},
+	{"ADAPTIVE_RESOURCE_LIMIT_TARGET_RATIO", PropLong},
+	{"ENERGY_FEE", PropLong},
+	{"MAX_CPU_TIME_OF_ONE_TX", PropLong},
+	{"CREATE_ACCOUNT_FEE", PropLong},
+	{"CREATE_NEW_ACCOUNT_FEE_IN_SYSTEM_CONTRACT", PropLong},
+	{"CREATE_NEW_ACCOUNT_BANDWIDTH_RATE", PropLong},
+	{"TRANSACTION_FEE", PropLong},
+	{"ASSET_ISSUE_FEE", PropLong},
+	{"UPDATE_ACCOUNT_PERMISSION_FEE", PropLong},
+	{"MULTI_SIGN_FEE", PropLong},
+	{"EXCHANGE_CREATE_FEE", PropLong},
+	{"EXCHANGE_BALANCE_LIMIT", PropLong},
+	{"MARKET_SELL_FEE", PropLong},
+	{"MARKET_CANCEL_FEE", PropLong},
+	{"MEMO_FEE", PropLong},
+	{"MAX_FEE_LIMIT", PropLong},
+	{"TOTAL_TRANSACTION_COST", PropLong},
+	{"TOTAL_CREATE_ACCOUNT_COST", PropLong},
+	{"TOTAL_CREATE_WITNESS_COST", PropLong},
+	{"TOTAL_STORAGE_POOL", PropLong},
+	{"TOTAL_STORAGE_TAX", PropLong},
+	{"TOTAL_STORAGE_RESERVED", PropLong},
+	{"STORAGE_EXCHANGE_TAX_RATE", PropLong},
+	{"TRANSACTION_FEE_POOL", PropLong},
+	{"BURN_TRX_AMOUNT", PropLong},
+	{"TOKEN_ID_NUM", PropLong},
+	{"TOKEN_UPDATE_DONE", PropBool},
+	{"UNFREEZE_DELAY_DAYS", PropLong},
+	{"MAX_DELEGATE_LOCK_PERIOD", PropLong},
+	{"MAX_CREATE_ACCOUNT_TX_SIZE", PropLong},
+	{"DYNAMIC_ENERGY_THRESHOLD", PropLong},
+	{"DYNAMIC_ENERGY_INCREASE_FACTOR", PropLong},
+	{"DYNAMIC_ENERGY_MAX_FACTOR", PropLong},
+	{"REMOVE_THE_POWER_OF_THE_GR", PropLong},
+	{"ALLOW_UPDATE_ACCOUNT_NAME", PropBool},
+	{"ALLOW_SAME_TOKEN_NAME", PropBool},
+	{"ALLOW_DELEGATE_RESOURCE", PropBool},
+	{"ALLOW_MULTI_SIGN", PropBool},
+	{"ALLOW_ADAPTIVE_ENERGY", PropBool},
+	{"ALLOW_CREATION_OF_CONTRACTS", PropBool},
+	{"ALLOW_TVM_TRANSFER_TRC10", PropBool},
+	{"ALLOW_TVM_CONSTANTINOPLE", PropBool},
+	{"ALLOW_TVM_SOLIDITY_059", PropBool},
+	{"ALLOW_TVM_ISTANBUL", PropBool},
+	{"ALLOW_TVM_FREEZE", PropBool},
+	{"ALLOW_TVM_VOTE", PropBool},
+	{"ALLOW_TVM_LONDON", PropBool},
+	{"ALLOW_TVM_COMPATIBLE_EVM", PropBool},
+	{"ALLOW_TVM_SHANGHAI", PropBool},
+	{"ALLOW_TVM_CANCUN", PropBool},
+	{"ALLOW_TVM_BLOB", PropBool},
+	{"FORBID_TRANSFER_TO_CONTRACT", PropBool},
+	{"ALLOW_PROTO_FILTER_NUM", PropBool},
+	{"ALLOW_ACCOUNT_STATE_ROOT", PropBool},
+	{"ALLOW_SHIELDED_TRANSACTION", PropBool},
+	{"ALLOW_SHIELDED_TRC20_TRANSACTION", PropBool},
+	{"ALLOW_MARKET_TRANSACTION", PropBool},
+	{"ALLOW_PBFT", PropBool},
+	{"ALLOW_TRANSACTION_FEE_POOL", PropBool},
+	{"ALLOW_BLACKHOLE_OPTIMIZATION", PropBool},
+	{"ALLOW_NEW_RESOURCE_MODEL", PropBool},
+	{"ALLOW_ACCOUNT_ASSET_OPTIMIZATION", PropBool},
+	{"ALLOW_ASSET_OPTIMIZATION", PropBool},
+	{"ALLOW_NEW_REWARD", PropBool},
+	{"ALLOW_HIGHER_LIMIT_FOR_MAX_CPU_TIME_OF_ONE_TX", PropBool},
+	{"ALLOW_DELEGATE_OPTIMIZATION", PropBool},
+	{"ALLOW_OPTIMIZED_RETURN_VALUE_OF_CHAIN_ID", PropBool},
+	{"ALLOW_DYNAMIC_ENERGY", PropBool},
+	{"ALLOW_CANCEL_ALL_UNFREEZE_V2", PropBool},
+	{"ALLOW_OLD_REWARD_OPT", PropBool},
+	{"ALLOW_ENERGY_ADJUSTMENT", PropBool},
+	{"ALLOW_STRICT_MATH", PropBool},
+	{"CONSENSUS_LOGIC_OPTIMIZATION", PropBool},
+}
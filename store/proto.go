@@ -0,0 +1,67 @@
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// protobuf wire types, see https://protobuf.dev/programming-guides/encoding/
+const (
+	wireVarint = 0
+	wireI64    = 1
+	wireLen    = 2
+	wireI32    = 5
+)
+
+var errTruncated = errors.New("truncated protobuf message")
+
+// protoField is a single decoded field, numeric fields are kept in Varint,
+// length-delimited fields (bytes, string, sub-message) in Bytes.
+type protoField struct {
+	Num    int
+	Wire   int
+	Varint uint64
+	Bytes  []byte
+}
+
+// decodeProto walks through all fields of the message without any schema.
+func decodeProto(data []byte) ([]protoField, error) {
+	var fields []protoField
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errTruncated
+		}
+		data = data[n:]
+		field := protoField{Num: int(tag >> 3), Wire: int(tag & 0x7)}
+		switch field.Wire {
+		case wireVarint:
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return nil, errTruncated
+			}
+			field.Varint, data = v, data[n:]
+		case wireI64:
+			if len(data) < 8 {
+				return nil, errTruncated
+			}
+			field.Varint, data = binary.LittleEndian.Uint64(data), data[8:]
+		case wireI32:
+			if len(data) < 4 {
+				return nil, errTruncated
+			}
+			field.Varint, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case wireLen:
+			l, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < l {
+				return nil, errTruncated
+			}
+			field.Bytes, data = data[n:n+int(l)], data[n+int(l):]
+		default:
+			return nil, fmt.Errorf("unsupported protobuf wire type %d", field.Wire)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
@@ -0,0 +1,99 @@
+//go:build rocksdb
+
+package store
+
+import (
+	"bytes"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+func init() {
+	RegisterEngine(EngineRocksDB, openRocksDB)
+}
+
+type rocksDB struct {
+	db   *grocksdb.DB
+	opts *grocksdb.Options
+	ro   *grocksdb.ReadOptions
+}
+
+func openRocksDB(path string) (DB, error) {
+	opts := grocksdb.NewDefaultOptions()
+	db, err := grocksdb.OpenDbForReadOnly(opts, path, false)
+	if err != nil {
+		opts.Destroy()
+		return nil, err
+	}
+	return &rocksDB{db: db, opts: opts, ro: grocksdb.NewDefaultReadOptions()}, nil
+}
+
+func (r *rocksDB) Get(key []byte) ([]byte, error) {
+	value, err := r.db.GetBytes(r.ro, key)
+	if err == nil && value == nil {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (r *rocksDB) NewIterator(slice *Range) Iterator {
+	if slice == nil {
+		slice = new(Range)
+	}
+	return &rocksIterator{itr: r.db.NewIterator(r.ro), slice: slice}
+}
+
+func (r *rocksDB) Close() error {
+	r.ro.Destroy()
+	r.db.Close()
+	r.opts.Destroy()
+	return nil
+}
+
+// rocksIterator copies key and value out of the C memory owned by the iterator
+type rocksIterator struct {
+	itr        *grocksdb.Iterator
+	slice      *Range
+	started    bool
+	key, value []byte
+}
+
+func (i *rocksIterator) Next() bool {
+	if !i.started {
+		i.started = true
+		if len(i.slice.Start) == 0 {
+			i.itr.SeekToFirst()
+		} else {
+			i.itr.Seek(i.slice.Start)
+		}
+	} else if i.itr.Valid() {
+		i.itr.Next()
+	}
+	if !i.itr.Valid() {
+		i.key, i.value = nil, nil
+		return false
+	}
+	i.key = bytes.Clone(i.itr.Key().Data())
+	if i.slice.Limit != nil && bytes.Compare(i.key, i.slice.Limit) >= 0 {
+		i.key, i.value = nil, nil
+		return false
+	}
+	i.value = bytes.Clone(i.itr.Value().Data())
+	return true
+}
+
+func (i *rocksIterator) Key() []byte {
+	return i.key
+}
+
+func (i *rocksIterator) Value() []byte {
+	return i.value
+}
+
+func (i *rocksIterator) Release() {
+	i.itr.Close()
+}
+
+func (i *rocksIterator) Error() error {
+	return i.itr.Err()
+}
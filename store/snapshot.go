@@ -0,0 +1,237 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// binary snapshot layout:
+//
+//	magic(5) | entries... | 0x00 | count(8, big-endian) | sha256 of all bytes before it(32)
+//
+// each entry is 0x01 | uvarint(len(key)) | key | uvarint(len(value)) | value
+var snapshotMagic = []byte("TTDB\x01")
+
+const (
+	snapshotEnd   = 0x00
+	snapshotEntry = 0x01
+
+	maxSnapshotItemSize = 1 << 30
+
+	SnapshotNDJSON = "ndjson"
+	SnapshotBinary = "binary"
+)
+
+// SnapshotEntry is a NDJSON line, Key and Value are always hex for a lossless import,
+// KeyText and ValueText are the optional decoded forms for human.
+type SnapshotEntry struct {
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+	KeyText   string `json:"key_text,omitempty"`
+	ValueText string `json:"value_text,omitempty"`
+}
+
+type SnapshotWriter interface {
+	Write(key, value []byte) error
+	// Close flushes the snapshot, the underlying writer is not closed.
+	Close() error
+}
+
+// NewSnapshotWriter creates the writer in the format, keyCodec and valueCodec are only used
+// by NDJSON to add the decoded text, both can be nil.
+func NewSnapshotWriter(w io.Writer, format string, keyCodec, valueCodec KeyCodec) (SnapshotWriter, error) {
+	switch format {
+	case SnapshotNDJSON, "":
+		return &ndjsonWriter{w: bufio.NewWriter(w), keyCodec: keyCodec, valueCodec: valueCodec}, nil
+	case SnapshotBinary:
+		writer := &binaryWriter{w: bufio.NewWriter(w), hasher: sha256.New()}
+		return writer, writer.write(snapshotMagic)
+	}
+	return nil, fmt.Errorf("unknown snapshot format `%s`, supported: %s|%s", format, SnapshotNDJSON, SnapshotBinary)
+}
+
+type ndjsonWriter struct {
+	w          *bufio.Writer
+	keyCodec   KeyCodec
+	valueCodec KeyCodec
+}
+
+func (n *ndjsonWriter) Write(key, value []byte) error {
+	entry := SnapshotEntry{Key: hex.EncodeToString(key), Value: hex.EncodeToString(value)}
+	if n.keyCodec != nil {
+		entry.KeyText = n.keyCodec.Encode(key)
+	}
+	if n.valueCodec != nil {
+		entry.ValueText = n.valueCodec.Encode(value)
+	}
+	line, err := json.Marshal(&entry)
+	if err != nil {
+		return err
+	}
+	if _, err := n.w.Write(line); err != nil {
+		return err
+	}
+	return n.w.WriteByte('\n')
+}
+
+func (n *ndjsonWriter) Close() error {
+	return n.w.Flush()
+}
+
+type binaryWriter struct {
+	w      *bufio.Writer
+	hasher hash.Hash
+	count  uint64
+}
+
+func (b *binaryWriter) write(data []byte) error {
+	b.hasher.Write(data)
+	_, err := b.w.Write(data)
+	return err
+}
+
+func (b *binaryWriter) Write(key, value []byte) error {
+	entry := []byte{snapshotEntry}
+	entry = binary.AppendUvarint(entry, uint64(len(key)))
+	entry = append(entry, key...)
+	entry = binary.AppendUvarint(entry, uint64(len(value)))
+	entry = append(entry, value...)
+	b.count++
+	return b.write(entry)
+}
+
+func (b *binaryWriter) Close() error {
+	footer := binary.BigEndian.AppendUint64([]byte{snapshotEnd}, b.count)
+	if err := b.write(footer); err != nil {
+		return err
+	}
+	if _, err := b.w.Write(b.hasher.Sum(nil)); err != nil {
+		return err
+	}
+	return b.w.Flush()
+}
+
+// ReadSnapshot reads all entries of the snapshot, the format is detected by the magic header.
+// For binary snapshots the count and checksum are verified after the last entry.
+func ReadSnapshot(r io.Reader, fn func(key, value []byte) error) error {
+	reader := bufio.NewReader(r)
+	head, err := reader.Peek(len(snapshotMagic))
+	if err == nil && bytes.Equal(head, snapshotMagic) {
+		return readBinarySnapshot(reader, fn)
+	}
+	return readNDJSONSnapshot(reader, fn)
+}
+
+func readNDJSONSnapshot(reader *bufio.Reader, fn func(key, value []byte) error) error {
+	decoder := json.NewDecoder(reader)
+	for line := 1; ; line++ {
+		var entry SnapshotEntry
+		if err := decoder.Decode(&entry); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		key, err := hex.DecodeString(entry.Key)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid key: %w", line, err)
+		}
+		value, err := hex.DecodeString(entry.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid value: %w", line, err)
+		}
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+}
+
+// hashReader feeds everything read into the hasher
+type hashReader struct {
+	r      *bufio.Reader
+	hasher hash.Hash
+}
+
+func (h *hashReader) ReadByte() (byte, error) {
+	c, err := h.r.ReadByte()
+	if err == nil {
+		h.hasher.Write([]byte{c})
+	}
+	return c, err
+}
+
+func (h *hashReader) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	h.hasher.Write(p[:n])
+	return n, err
+}
+
+func readBinarySnapshot(reader *bufio.Reader, fn func(key, value []byte) error) error {
+	r := &hashReader{r: reader, hasher: sha256.New()}
+	if _, err := io.ReadFull(r, make([]byte, len(snapshotMagic))); err != nil {
+		return err
+	}
+	var count uint64
+	for {
+		tag, err := r.ReadByte()
+		if err != nil {
+			return fmt.Errorf("snapshot is truncated: %w", err)
+		}
+		if tag == snapshotEnd {
+			break
+		}
+		if tag != snapshotEntry {
+			return fmt.Errorf("invalid entry tag 0x%02x at entry %d", tag, count)
+		}
+		key, err := readLenPrefixed(r)
+		if err != nil {
+			return err
+		}
+		value, err := readLenPrefixed(r)
+		if err != nil {
+			return err
+		}
+		if err := fn(key, value); err != nil {
+			return err
+		}
+		count++
+	}
+	footer := make([]byte, 8)
+	if _, err := io.ReadFull(r, footer); err != nil {
+		return fmt.Errorf("snapshot is truncated: %w", err)
+	}
+	sum := r.hasher.Sum(nil)
+	checksum := make([]byte, len(sum))
+	if _, err := io.ReadFull(reader, checksum); err != nil {
+		return fmt.Errorf("snapshot is truncated: %w", err)
+	}
+	if expected := binary.BigEndian.Uint64(footer); expected != count {
+		return fmt.Errorf("snapshot count mismatch: footer %d, read %d", expected, count)
+	}
+	if !bytes.Equal(sum, checksum) {
+		return fmt.Errorf("snapshot checksum mismatch: expected %x, got %x", checksum, sum)
+	}
+	return nil
+}
+
+func readLenPrefixed(r *hashReader) ([]byte, error) {
+	l, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot is truncated: %w", err)
+	}
+	if l > maxSnapshotItemSize {
+		return nil, fmt.Errorf("snapshot item size %d is too large", l)
+	}
+	data := make([]byte, l)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("snapshot is truncated: %w", err)
+	}
+	return data, nil
+}
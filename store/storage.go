@@ -0,0 +1,82 @@
+package store
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const storagePrefixBytes = 16
+
+// SmartContract is the decoded `contract` store entry, only the fields we need are kept.
+type SmartContract struct {
+	OriginAddress []byte
+	Address       []byte
+	Bytecode      []byte
+	Name          string
+	CodeHash      []byte
+	TrxHash       []byte
+	Version       int32
+}
+
+func DecodeSmartContract(data []byte) (*SmartContract, error) {
+	fields, err := decodeProto(data)
+	if err != nil {
+		return nil, err
+	}
+	contract := new(SmartContract)
+	for _, f := range fields {
+		switch f.Num {
+		case 1:
+			contract.OriginAddress = f.Bytes
+		case 2:
+			contract.Address = f.Bytes
+		case 4:
+			contract.Bytecode = f.Bytes
+		case 7:
+			contract.Name = string(f.Bytes)
+		case 9:
+			contract.CodeHash = f.Bytes
+		case 10:
+			contract.TrxHash = f.Bytes
+		case 11:
+			contract.Version = int32(f.Varint)
+		}
+	}
+	return contract, nil
+}
+
+// StorageAddrHash is the prefix source of all storage rows of the contract, java-tron mixes
+// the creating trx hash into it for contracts having one (created by CREATE2 after the fix).
+func StorageAddrHash(addr []byte, trxHash []byte) []byte {
+	if len(trxHash) == 0 || allZero(trxHash) {
+		return crypto.Keccak256(addr)
+	}
+	return crypto.Keccak256(addr, trxHash)
+}
+
+// StorageRowKey composes the `storage-row` key the same as java-tron Storage.compose,
+// addr must be the 21 bytes address, contracts of version 1 hash the slot first.
+func StorageRowKey(addr []byte, slot common.Hash, contract *SmartContract) []byte {
+	key := slot.Bytes()
+	var trxHash []byte
+	if contract != nil {
+		trxHash = contract.TrxHash
+		if contract.Version == 1 {
+			key = crypto.Keccak256(key)
+		}
+	}
+	addrHash := StorageAddrHash(addr, trxHash)
+	rowKey := make([]byte, len(key))
+	copy(rowKey, addrHash[:storagePrefixBytes])
+	copy(rowKey[storagePrefixBytes:], key[storagePrefixBytes:])
+	return rowKey
+}
+
+func allZero(s []byte) bool {
+	for _, v := range s {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -1,10 +1,17 @@
 package main
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
 
 	"github.com/btcsuite/btcd/btcutil/base58"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/urfave/cli/v2"
 	"tools/log"
@@ -13,11 +20,13 @@ import (
 
 var (
 	signCommand = cli.Command{
-		Name:  "sign",
-		Usage: "Sign a message with private key",
+		Name:      "sign",
+		Usage:     "Sign a message with private key",
+		ArgsUsage: "<msg-hash> [private-key]",
+		Flags:     keySourceFlags,
 		Action: func(c *cli.Context) error {
-			if c.NArg() != 2 {
-				return errors.New("sign subcommand needs msg-hash and private-key args")
+			if c.NArg() != 1 && c.NArg() != 2 {
+				return errors.New("sign subcommand needs msg-hash arg")
 			}
 			msgHash, ok := utils.FromHex(c.Args().Get(0))
 			if ok {
@@ -28,17 +37,7 @@ var (
 				return errors.New("msg-hash must be in hex format")
 			}
 
-			privateKey, ok := utils.FromHex(c.Args().Get(1))
-			if ok {
-				if len(privateKey) != 32 {
-					return errors.New("private-key must be 32 bytes")
-				}
-			} else {
-				return errors.New("private-key must be in hex format")
-			}
-
-			pub, err := crypto.ToECDSA(privateKey)
-
+			pub, err := loadPrivateKey(c, c.Args().Get(1))
 			if err != nil {
 				return err
 			}
@@ -57,8 +56,9 @@ var (
 		},
 	}
 	recoverCommand = cli.Command{
-		Name:  "recover",
-		Usage: "Recover address from signature",
+		Name:      "recover",
+		Usage:     "Recover address and public key from signature",
+		ArgsUsage: "<msg-hash> <signature>",
 		Action: func(c *cli.Context) error {
 			if c.NArg() != 2 {
 				return errors.New("recover subcommand needs msg-hash and sig args")
@@ -72,17 +72,19 @@ var (
 				return errors.New("msg-hash must be in hex format")
 			}
 
-			sig, ok := utils.FromHex(c.Args().Get(1))
-			if ok {
-				if len(sig) != 65 {
-					return errors.New("signature must be 65 bytes")
-				}
-			} else {
+			sigBytes, ok := utils.FromHex(c.Args().Get(1))
+			if !ok {
 				return errors.New("signature must be in hex format")
 			}
-
-			pub, err := crypto.SigToPub(msgHash, sig)
-
+			sig, err := utils.ParseSignature(sigBytes)
+			if err != nil {
+				return err
+			}
+			pubBytes, err := sig.RecoverPubkey(msgHash)
+			if err != nil {
+				return err
+			}
+			pub, err := crypto.UnmarshalPubkey(pubBytes)
 			if err != nil {
 				return err
 			}
@@ -90,8 +92,277 @@ var (
 			addr := crypto.PubkeyToAddress(*pub)
 			log.NewLog("eth addr", addr.String())
 			log.NewLog("tron addr", base58.CheckEncode(addr.Bytes(), 0x41))
+			log.NewLog("pubkey", hexutil.Encode(pubBytes))
+			log.NewLog("compressed", hexutil.Encode(crypto.CompressPubkey(pub)))
 
 			return nil
 		},
 	}
+	sigCommand = cli.Command{
+		Name:      "sig",
+		Usage:     "Split the signature and convert it between r‖s‖v, EIP-155, EIP-2098 compact and DER",
+		ArgsUsage: "<signature>",
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return errors.New("sig subcommand needs signature arg")
+			}
+			data, ok := utils.FromHex(c.Args().Get(0))
+			if !ok {
+				return errors.New("signature must be in hex format")
+			}
+			sig, err := utils.ParseSignature(data)
+			if err != nil {
+				return err
+			}
+			log.NewLog("format", sig.Format)
+			log.NewLog("r", fmt.Sprintf("0x%064x", sig.R))
+			log.NewLog("s", fmt.Sprintf("0x%064x", sig.S))
+			if sig.RecoveryId >= 0 {
+				log.NewLog("v", fmt.Sprintf("%s (recovery id %d)", sig.V, sig.RecoveryId))
+			} else {
+				log.NewLog("v", "unknown, DER has no recovery id")
+			}
+			if sig.ChainId != nil {
+				log.NewLog("chain id", sig.ChainId.String())
+			}
+			if sig.HighS() {
+				log.NewLog("high s", "malleable, the forms below are normalized to low-s")
+				sig = sig.Normalize()
+				log.NewLog("low s", fmt.Sprintf("0x%064x", sig.S))
+			}
+			if sig.RecoveryId >= 0 {
+				rsv, _ := sig.RSV(27)
+				log.NewLog("rsv (27/28)", hexutil.Encode(rsv))
+				rsv, _ = sig.RSV(0)
+				log.NewLog("rsv (0/1)", hexutil.Encode(rsv))
+				if sig.ChainId != nil {
+					rsv, _ = sig.EIP155(sig.ChainId)
+					log.NewLog("rsv (EIP-155)", hexutil.Encode(rsv))
+				}
+				compact, _ := sig.Compact()
+				log.NewLog("compact", hexutil.Encode(compact))
+			}
+			der, err := sig.DER()
+			if err != nil {
+				return err
+			}
+			log.NewLog("der", hexutil.Encode(der))
+			return nil
+		},
+	}
+	msgSchemeFlag = &cli.StringFlag{
+		Name:  "scheme",
+		Value: utils.MessageTIP191,
+		Usage: "message prefix scheme, one of " + strings.Join(utils.MessageSchemes, ", "),
+	}
+	signMsgCommand = cli.Command{
+		Name:      "signmsg",
+		Usage:     "Sign a text or hex message with TRON or Ethereum message prefix",
+		ArgsUsage: "<message> [private-key]",
+		Flags:     append([]cli.Flag{msgSchemeFlag}, keySourceFlags...),
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 && c.NArg() != 2 {
+				return errors.New("signmsg subcommand needs message arg")
+			}
+			key, err := loadPrivateKey(c, c.Args().Get(1))
+			if err != nil {
+				return err
+			}
+			scheme := c.String(msgSchemeFlag.Name)
+			hash, err := utils.HashMessage(scheme, utils.ParseMessage(c.Args().Get(0)))
+			if err != nil {
+				return err
+			}
+			sig, err := crypto.Sign(hash, key)
+			if err != nil {
+				return err
+			}
+			// v is 27/28 like TronWeb and Ethereum wallets
+			sig[64] += 27
+			addr := crypto.PubkeyToAddress(key.PublicKey)
+			log.NewLog("scheme", scheme)
+			log.NewLog("hash", hexutil.Encode(hash))
+			log.NewLog("eth addr", addr.String())
+			log.NewLog("tron addr", base58.CheckEncode(addr.Bytes(), 0x41))
+			log.NewLog("signature", hexutil.Encode(sig))
+			return nil
+		},
+	}
+	verifyMsgCommand = cli.Command{
+		Name:      "verifymsg",
+		Usage:     "Recover the signer of a message signature, all schemes are tried if the address is given without --scheme",
+		ArgsUsage: "<message> <signature> [address]",
+		Flags:     []cli.Flag{msgSchemeFlag},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 2 && c.NArg() != 3 {
+				return errors.New("verifymsg subcommand needs message and signature args")
+			}
+			message := utils.ParseMessage(c.Args().Get(0))
+			sig, ok := utils.FromHex(c.Args().Get(1))
+			if !ok {
+				return errors.New("signature must be in hex format")
+			}
+			var expected []byte
+			if c.NArg() == 3 {
+				if expected, ok = utils.ToAddress(c.Args().Get(2)); !ok {
+					return errors.New("invalid address")
+				}
+			}
+			schemes := []string{c.String(msgSchemeFlag.Name)}
+			if expected != nil && !c.IsSet(msgSchemeFlag.Name) {
+				schemes = utils.MessageSchemes
+			}
+			for _, scheme := range schemes {
+				hash, err := utils.HashMessage(scheme, message)
+				if err != nil {
+					return err
+				}
+				addr, err := recoverAddress(hash, sig)
+				if err != nil {
+					return err
+				}
+				if expected != nil && len(schemes) > 1 && !bytes.Equal(addr.Bytes(), expected) {
+					continue
+				}
+				log.NewLog("scheme", scheme)
+				log.NewLog("hash", hexutil.Encode(hash))
+				log.NewLog("eth addr", addr.String())
+				log.NewLog("tron addr", base58.CheckEncode(addr.Bytes(), 0x41))
+				if expected != nil {
+					if !bytes.Equal(addr.Bytes(), expected) {
+						return errors.New("signature does not match the address")
+					}
+					log.NewLog("result", "valid")
+				}
+				return nil
+			}
+			return errors.New("signature does not match the address in any scheme")
+		},
+	}
+	typedTronFlag = &cli.BoolFlag{
+		Name:  "tron",
+		Usage: "TIP-712, mask the chainId to 4 bytes (enabled if any base58 address is found)",
+	}
+	typedCommand = cli.Command{
+		Name:  "typed",
+		Usage: "Hash, sign or verify EIP-712 (and TIP-712) typed data",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "hash",
+				Usage:     "Compute the domain separator, struct hash and digest",
+				ArgsUsage: "<typed-data.json|->",
+				Flags:     []cli.Flag{typedTronFlag},
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return errors.New("hash subcommand needs typed data file arg")
+					}
+					_, err := hashTypedData(c, c.Args().Get(0))
+					return err
+				},
+			},
+			{
+				Name:      "sign",
+				Usage:     "Sign typed data with private key",
+				ArgsUsage: "<typed-data.json|-> [private-key]",
+				Flags:     append([]cli.Flag{typedTronFlag}, keySourceFlags...),
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 && c.NArg() != 2 {
+						return errors.New("sign subcommand needs typed data file arg")
+					}
+					key, err := loadPrivateKey(c, c.Args().Get(1))
+					if err != nil {
+						return err
+					}
+					digest, err := hashTypedData(c, c.Args().Get(0))
+					if err != nil {
+						return err
+					}
+					sig, err := crypto.Sign(digest, key)
+					if err != nil {
+						return err
+					}
+					// wallets return v as 27/28 for typed data
+					sig[64] += 27
+					addr := crypto.PubkeyToAddress(key.PublicKey)
+					log.NewLog("eth addr", addr.String())
+					log.NewLog("tron addr", base58.CheckEncode(addr.Bytes(), 0x41))
+					log.NewLog("signature", hexutil.Encode(sig))
+					return nil
+				},
+			},
+			{
+				Name:      "verify",
+				Usage:     "Recover the signer of typed data signature",
+				ArgsUsage: "<typed-data.json|-> <signature>",
+				Flags:     []cli.Flag{typedTronFlag},
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 2 {
+						return errors.New("verify subcommand needs typed data file and signature args")
+					}
+					sig, ok := utils.FromHex(c.Args().Get(1))
+					if !ok {
+						return errors.New("signature must be in hex format")
+					}
+					digest, err := hashTypedData(c, c.Args().Get(0))
+					if err != nil {
+						return err
+					}
+					addr, err := recoverAddress(digest, sig)
+					if err != nil {
+						return err
+					}
+					log.NewLog("eth addr", addr.String())
+					log.NewLog("tron addr", base58.CheckEncode(addr.Bytes(), 0x41))
+					return nil
+				},
+			},
+		},
+	}
 )
+
+// readInput reads the file, or stdin if path is `-`
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// hashTypedData loads the typed data, logs the intermediate hashes and returns the digest
+func hashTypedData(c *cli.Context, path string) ([]byte, error) {
+	data, err := readInput(path)
+	if err != nil {
+		return nil, err
+	}
+	typed, err := utils.LoadTypedData(data, c.Bool(typedTronFlag.Name))
+	if err != nil {
+		return nil, err
+	}
+	hashes, err := utils.HashTypedData(typed)
+	if err != nil {
+		return nil, err
+	}
+	log.NewLog("domain type", hashes.DomainType)
+	if typed.Domain.ChainId != nil {
+		log.NewLog("chain id", (*big.Int)(typed.Domain.ChainId).String())
+	}
+	log.NewLog("domain separator", hexutil.Encode(hashes.DomainSeparator))
+	log.NewLog("primary type", hashes.PrimaryType)
+	log.NewLog("type hash", hexutil.Encode(hashes.TypeHash))
+	log.NewLog("struct hash", hexutil.Encode(hashes.StructHash))
+	log.NewLog("digest", hexutil.Encode(hashes.Digest))
+	return hashes.Digest, nil
+}
+
+// recoverAddress recovers the signer, the signature can be in any form of utils.ParseSignature
+func recoverAddress(hash, data []byte) (common.Address, error) {
+	sig, err := utils.ParseSignature(data)
+	if err != nil {
+		return common.Address{}, err
+	}
+	pub, err := sig.RecoverPubkey(hash)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(crypto.Keccak256(pub[1:])[12:]), nil
+}
@@ -31,31 +31,15 @@ func EncodeCallData(inputSignature string, params []string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	if len(typeList) != len(params) {
-		return "", fmt.Errorf("param count mismatch: signature expects %d args, got %d", len(typeList), len(params))
-	}
-
-	abiJSON := buildSingleFunctionABIJSON(name, typeList)
-	parsedABI, err := abi.JSON(strings.NewReader(abiJSON))
+	method, args, err := convertParams(name, typeList, params)
 	if err != nil {
-		return "", fmt.Errorf("abi.JSON parse failed: %w", err)
-	}
-
-	method := parsedABI.Methods[name]
-
-	args := make([]any, len(params))
-	for i := range params {
-		v, err := convertStringToABIValue(method.Inputs[i].Type, params[i])
-		if err != nil {
-			return "", fmt.Errorf("convert arg[%d] (%s) failed: %w", i, method.Inputs[i].Type.String(), err)
-		}
-		args[i] = v
+		return "", err
 	}
-
-	data, err := parsedABI.Pack(name, args...)
+	data, err := method.Inputs.Pack(args...)
 	if err != nil {
 		return "", fmt.Errorf("abi.Pack failed: %w", err)
 	}
+	data = append(append([]byte{}, method.ID...), data...)
 	return hexutil.Encode(data), nil
 }
 
@@ -76,7 +60,7 @@ func Selector(inputSignature string) (string, error) {
 /* ------------------------- Signature parsing ------------------------- */
 
 var sigRe = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\((.*)\)$`)
-var solFnRe = regexp.MustCompile(`(?s)\bfunction\s+([A-Za-z_][A-Za-z0-9_]*)\s*\((.*)\)`)
+var solFnRe = regexp.MustCompile(`(?s)\bfunction\s+([A-Za-z_][A-Za-z0-9_]*)\s*\((.*)`)
 
 func parseFunctionLikeInput(input string) (name string, types []string, err error) {
 	in := strings.TrimSpace(input)
@@ -121,25 +105,35 @@ func parseSolidityFunctionDef(def string) (name string, types []string, err erro
 	if err != nil {
 		return "", nil, err
 	}
+	types, err = parseSolidityParams(paramsPart, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, types, nil
+}
+
+// parseSolidityParams converts the params of a declaration to canonical ABI types, user defined
+// types are resolved by udt, which can be nil if there are none.
+func parseSolidityParams(paramsPart string, udt *sigTypes) ([]string, error) {
 	paramsPart = strings.TrimSpace(paramsPart)
 	if paramsPart == "" {
-		return name, []string{}, nil
+		return []string{}, nil
 	}
 
 	params, err := splitCommaRespectNesting(paramsPart)
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
 
-	types = make([]string, 0, len(params))
+	types := make([]string, 0, len(params))
 	for _, p := range params {
-		ty, err := solidityParamToABIType(p)
+		ty, err := solidityParamToABIType(p, udt, 0)
 		if err != nil {
-			return "", nil, fmt.Errorf("param %q: %w", p, err)
+			return nil, fmt.Errorf("param %q: %w", p, err)
 		}
 		types = append(types, ty)
 	}
-	return name, types, nil
+	return types, nil
 }
 
 func buildSingleFunctionABIJSON(name string, types []string) string {
@@ -225,12 +219,16 @@ func takeUntilMatchingParen(s string) (string, error) {
 	return "", fmt.Errorf("unbalanced parentheses in solidity function definition")
 }
 
-func solidityParamToABIType(param string) (string, error) {
+// solidityParamToABIType returns the canonical type of a param like `uint[] calldata amounts`,
+// depth counts the nested struct members.
+func solidityParamToABIType(param string, udt *sigTypes, depth int) (string, error) {
 	p := strings.TrimSpace(param)
 	if p == "" {
 		return "", fmt.Errorf("empty param")
 	}
 	p = strings.Join(strings.Fields(p), " ")
+	// `uint [] x` is valid solidity, join the array suffix back to the type
+	p = spaceArrRe.ReplaceAllString(p, "[")
 
 	// strip common location qualifiers
 	for _, q := range []string{" memory ", " calldata ", " storage "} {
@@ -250,7 +248,7 @@ func solidityParamToABIType(param string) (string, error) {
 		if err != nil {
 			return "", err
 		}
-		return tupleType, nil
+		return udt.canonical(tupleType, depth)
 	}
 
 	// otherwise first token is type (may include []/[k])
@@ -259,7 +257,7 @@ func solidityParamToABIType(param string) (string, error) {
 	if ty == "" {
 		return "", fmt.Errorf("cannot parse type from %q", param)
 	}
-	return ty, nil
+	return udt.canonical(ty, depth)
 }
 
 func takeTupleType(s string) (tupleType string, rest string, err error) {
@@ -408,6 +406,20 @@ func convertStringToABIValue(t abi.Type, s string) (any, error) {
 		if err != nil {
 			return nil, err
 		}
+		// int8..int64 and uint8..uint64 are native go ints in go-ethereum
+		goTy := t.GetType()
+		switch goTy.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if !bi.IsInt64() || reflect.Zero(goTy).OverflowInt(bi.Int64()) {
+				return nil, fmt.Errorf("%s overflows %s", bi, t.String())
+			}
+			return reflect.ValueOf(bi.Int64()).Convert(goTy).Interface(), nil
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if !bi.IsUint64() || reflect.Zero(goTy).OverflowUint(bi.Uint64()) {
+				return nil, fmt.Errorf("%s overflows %s", bi, t.String())
+			}
+			return reflect.ValueOf(bi.Uint64()).Convert(goTy).Interface(), nil
+		}
 		return bi, nil
 
 	case abi.TupleTy:
@@ -0,0 +1,313 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+/* ------------------------- ABI files ------------------------- */
+
+// ABIEntries extracts the ABI entries from plain ABI json, Hardhat/Foundry/Truffle artifacts
+// (`abi` field), or the TRON `wallet/getcontract` response (`abi.entrys`).
+// TRON capitalizes `type` and `stateMutability`, they are lowercased for abi.JSON.
+func ABIEntries(data []byte) ([]map[string]interface{}, error) {
+	var entries []map[string]interface{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(data, &object); err != nil {
+			return nil, err
+		}
+		if raw, ok := object["abi"]; ok {
+			return ABIEntries(raw)
+		}
+		raw, ok := object["entrys"]
+		if !ok {
+			return nil, errors.New("no abi found in the json")
+		}
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			return nil, err
+		}
+	}
+	for _, entry := range entries {
+		for _, key := range []string{"type", "stateMutability"} {
+			if value, ok := entry[key].(string); ok {
+				entry[key] = strings.ToLower(value)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// ParseABI parses the ABI in any format accepted by ABIEntries.
+func ParseABI(data []byte) (*abi.ABI, error) {
+	entries, err := ABIEntries(data)
+	if err != nil {
+		return nil, err
+	}
+	data, err = json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := abi.JSON(strings.NewReader(string(data)))
+	return &parsed, err
+}
+
+// LoadABIPath loads the ABI file, or merges all json files with ABI in the dir (recursively),
+// the methods having the same signature are only kept once.
+func LoadABIPath(path string) (*abi.ABI, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return ParseABI(data)
+	}
+	merged := &abi.ABI{
+		Methods: make(map[string]abi.Method),
+		Events:  make(map[string]abi.Event),
+		Errors:  make(map[string]abi.Error),
+	}
+	// the same interface is in many artifacts, dedupe by the signature and the event or error id
+	seen := make(map[string]bool)
+	seenEvents := make(map[common.Hash]bool)
+	seenErrors := make(map[common.Hash]bool)
+	err = filepath.WalkDir(path, func(file string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(file) != ".json" {
+			return err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		// not every json in the build dir is an artifact, like build-info or cache
+		parsed, err := ParseABI(data)
+		if err != nil {
+			return nil
+		}
+		for name, method := range parsed.Methods {
+			if seen[method.Sig] {
+				continue
+			}
+			seen[method.Sig] = true
+			if _, ok := merged.Methods[name]; ok {
+				name = method.Sig
+			}
+			merged.Methods[name] = method
+		}
+		for name, event := range parsed.Events {
+			if seenEvents[event.ID] {
+				continue
+			}
+			seenEvents[event.ID] = true
+			if _, ok := merged.Events[name]; ok {
+				name = event.Sig
+			}
+			merged.Events[name] = event
+		}
+		for name, abiErr := range parsed.Errors {
+			if seenErrors[abiErr.ID] {
+				continue
+			}
+			seenErrors[abiErr.ID] = true
+			if _, ok := merged.Errors[name]; ok {
+				name = abiErr.Sig
+			}
+			merged.Errors[name] = abiErr
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(merged.Methods)+len(merged.Events)+len(merged.Errors) == 0 {
+		return nil, fmt.Errorf("no abi found in %s", path)
+	}
+	return merged, nil
+}
+
+// MethodFromSignature builds the method without outputs from signature like `transfer(address,uint256)`
+// or solidity function definition.
+func MethodFromSignature(signature string) (abi.Method, error) {
+	name, typeList, err := parseFunctionLikeInput(signature)
+	if err != nil {
+		return abi.Method{}, err
+	}
+	parsed, err := abi.JSON(strings.NewReader(buildSingleFunctionABIJSON(name, typeList)))
+	if err != nil {
+		return abi.Method{}, err
+	}
+	return parsed.Methods[name], nil
+}
+
+/* ------------------------- ABI cache ------------------------- */
+
+// ABICache keeps the ABI entries json of contracts in `<dir>/<network>/<address>.json`,
+// the file modification time is the fetched time.
+type ABICache struct {
+	Dir string
+	TTL time.Duration
+}
+
+type ABICacheItem struct {
+	Network string
+	Address string
+	Size    int64
+	Fetched time.Time
+}
+
+// NewABICache uses the user cache dir, like ~/.cache/tt/abi on linux.
+func NewABICache(ttl time.Duration) (*ABICache, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &ABICache{Dir: filepath.Join(dir, "tt", "abi"), TTL: ttl}, nil
+}
+
+// cacheFile is the file of the address in base58, so hex and base58 inputs share the entry and
+// nothing outside the cache dir can be named.
+func cacheFile(address string) (string, error) {
+	addr, ok := ToAddress(address)
+	if !ok {
+		return "", fmt.Errorf("invalid address %s", address)
+	}
+	return ToBase58(addr) + ".json", nil
+}
+
+func (c *ABICache) path(network, address string) (string, error) {
+	name, err := cacheFile(address)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(c.Dir, filepath.Base(network), name), nil
+}
+
+// proxyPath keeps the detected proxy of the address, out of the `*.json` glob of List.
+func (c *ABICache) proxyPath(network, address string) (string, error) {
+	name, err := cacheFile(address)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(c.Dir, filepath.Base(network), "proxy", name), nil
+}
+
+// Get returns the cached entries json, expired items are treated as missing.
+func (c *ABICache) Get(network, address string) ([]byte, bool) {
+	file, err := c.path(network, address)
+	if err != nil {
+		return nil, false
+	}
+	return c.read(file)
+}
+
+func (c *ABICache) Put(network, address string, data []byte) error {
+	file, err := c.path(network, address)
+	if err != nil {
+		return err
+	}
+	return c.write(file, data)
+}
+
+// GetProxy returns the cached proxy json of the address, like Get.
+func (c *ABICache) GetProxy(network, address string) ([]byte, bool) {
+	file, err := c.proxyPath(network, address)
+	if err != nil {
+		return nil, false
+	}
+	return c.read(file)
+}
+
+func (c *ABICache) PutProxy(network, address string, data []byte) error {
+	file, err := c.proxyPath(network, address)
+	if err != nil {
+		return err
+	}
+	return c.write(file, data)
+}
+
+func (c *ABICache) read(file string) ([]byte, bool) {
+	info, err := os.Stat(file)
+	if err != nil || time.Since(info.ModTime()) > c.TTL {
+		return nil, false
+	}
+	data, err := os.ReadFile(file)
+	return data, err == nil
+}
+
+func (c *ABICache) write(file string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0o644)
+}
+
+// List returns all cached items sorted by network and address, expired ones included.
+func (c *ABICache) List() ([]ABICacheItem, error) {
+	files, err := filepath.Glob(filepath.Join(c.Dir, "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var items []ABICacheItem
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		items = append(items, ABICacheItem{
+			Network: filepath.Base(filepath.Dir(file)),
+			Address: strings.TrimSuffix(filepath.Base(file), ".json"),
+			Size:    info.Size(),
+			Fetched: info.ModTime(),
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Network != items[j].Network {
+			return items[i].Network < items[j].Network
+		}
+		return items[i].Address < items[j].Address
+	})
+	return items, nil
+}
+
+// Clear removes the cached address in all networks, or everything if address is empty.
+func (c *ABICache) Clear(address string) (int, error) {
+	if len(address) != 0 {
+		name, err := cacheFile(address)
+		if err != nil {
+			return 0, err
+		}
+		address = strings.TrimSuffix(name, ".json")
+	}
+	items, err := c.List()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, item := range items {
+		if len(address) != 0 && item.Address != address {
+			continue
+		}
+		dir := filepath.Join(c.Dir, item.Network)
+		if err := os.Remove(filepath.Join(dir, item.Address+".json")); err != nil {
+			return count, err
+		}
+		if err := os.Remove(filepath.Join(dir, "proxy", item.Address+".json")); err != nil && !os.IsNotExist(err) {
+			return count, err
+		}
+		count += 1
+	}
+	return count, nil
+}
@@ -2,16 +2,31 @@ package utils
 
 import "github.com/btcsuite/btcd/btcutil/base58"
 
+// ToAddress returns the 20 bytes address of TRON base58, 0x hex or 0x41 prefixed hex.
 func ToAddress(s string) ([]byte, bool) {
 	if len(s) == 34 && s[0] == 'T' {
 		addrBytes, _, err := base58.CheckDecode(s)
 		if err == nil {
 			return addrBytes, true
 		}
-	} else {
-		if addrBytes, ok := FromHex(s); ok && len(addrBytes) == 20 {
+	} else if addrBytes, ok := FromHex(s); ok {
+		switch {
+		case len(addrBytes) == 20:
 			return addrBytes, true
+		case len(addrBytes) == 21 && addrBytes[0] == 0x41:
+			return addrBytes[1:], true
 		}
 	}
 	return nil, false
 }
+
+// ToBase58 encodes the address in TRON base58, both 20 bytes and 21 bytes (with 0x41 prefix) are accepted.
+func ToBase58(addr []byte) string {
+	switch len(addr) {
+	case 20:
+		return base58.CheckEncode(addr, 0x41)
+	case 21:
+		return base58.CheckEncode(addr[1:], addr[0])
+	}
+	return ""
+}
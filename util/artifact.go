@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+/* ------------------------- Build artifacts ------------------------- */
+
+// CodeRange is a range of the code ignored by comparison, like immutables and library addresses.
+type CodeRange struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+// Artifact is the build output of a contract, Masks are the ranges of library placeholders in
+// the creation code, and of immutables and library placeholders in the runtime code. Immutables
+// tells if the runtime code has `immutableReferences`, Hardhat and Truffle artifacts don't.
+type Artifact struct {
+	ABI           *abi.ABI
+	Creation      []byte
+	Runtime       []byte
+	CreationMasks []CodeRange
+	RuntimeMasks  []CodeRange
+	Immutables    bool
+}
+
+// libraryPlaceholder is `__$<34 hex>$__` of solc >= 0.5 or `__<path:name padded by _>` of older,
+// both are 40 chars in place of the address
+var libraryPlaceholder = regexp.MustCompile(`__\$[0-9a-fA-F]{34}\$__|__[^$].{37}`)
+
+// LoadArtifact parses Hardhat/Truffle (`bytecode` and `deployedBytecode` in hex), Foundry (`object`
+// of them with `immutableReferences`) and solc standard json output (`evm.bytecode`) artifacts.
+func LoadArtifact(data []byte) (*Artifact, error) {
+	var raw struct {
+		Bytecode         json.RawMessage `json:"bytecode"`
+		DeployedBytecode json.RawMessage `json:"deployedBytecode"`
+		EVM              *struct {
+			Bytecode         json.RawMessage `json:"bytecode"`
+			DeployedBytecode json.RawMessage `json:"deployedBytecode"`
+		} `json:"evm"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if raw.EVM != nil {
+		raw.Bytecode, raw.DeployedBytecode = raw.EVM.Bytecode, raw.EVM.DeployedBytecode
+	}
+	artifact := new(Artifact)
+	var err error
+	if artifact.Creation, artifact.CreationMasks, _, err = parseArtifactCode(raw.Bytecode); err != nil {
+		return nil, fmt.Errorf("invalid bytecode: %v", err)
+	}
+	if artifact.Runtime, artifact.RuntimeMasks, artifact.Immutables, err = parseArtifactCode(raw.DeployedBytecode); err != nil {
+		return nil, fmt.Errorf("invalid deployedBytecode: %v", err)
+	}
+	if len(artifact.Creation) == 0 && len(artifact.Runtime) == 0 {
+		return nil, errors.New("no bytecode found in the artifact")
+	}
+	// the constructor ABI is optional for the comparison
+	if parsed, err := ParseABI(data); err == nil {
+		artifact.ABI = parsed
+	}
+	return artifact, nil
+}
+
+// parseArtifactCode parses the hex or the object with `object` and `immutableReferences`, it tells
+// if the immutableReferences are given.
+func parseArtifactCode(raw json.RawMessage) ([]byte, []CodeRange, bool, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil, false, nil
+	}
+	var object struct {
+		Object              string                 `json:"object"`
+		ImmutableReferences map[string][]CodeRange `json:"immutableReferences"`
+	}
+	if err := json.Unmarshal(raw, &object.Object); err != nil {
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return nil, nil, false, err
+		}
+	}
+	text := strings.TrimPrefix(object.Object, "0x")
+	var masks []CodeRange
+	for _, loc := range libraryPlaceholder.FindAllStringIndex(text, -1) {
+		masks = append(masks, CodeRange{Start: loc[0] / 2, Length: (loc[1] - loc[0]) / 2})
+	}
+	text = libraryPlaceholder.ReplaceAllStringFunc(text, func(s string) string {
+		return strings.Repeat("0", len(s))
+	})
+	code, err := hexutil.Decode("0x" + text)
+	if err != nil && len(text) != 0 {
+		return nil, nil, false, err
+	}
+	for _, refs := range object.ImmutableReferences {
+		masks = append(masks, refs...)
+	}
+	sort.Slice(masks, func(i, j int) bool { return masks[i].Start < masks[j].Start })
+	return code, masks, object.ImmutableReferences != nil, nil
+}
+
+// MetadataRanges finds the solc metadata trailers in the code, the creation code has the one of
+// the runtime code, and of the contracts it creates.
+func MetadataRanges(code []byte) []CodeRange {
+	var ranges []CodeRange
+	for i := 0; i < len(code); i++ {
+		// solc metadata is a map of 1 to 5 entries, like "ipfs", "bzzr0", "bzzr1", "solc" and "experimental"
+		if code[i] < 0xa1 || code[i] > 0xa5 {
+			continue
+		}
+		for end := i + 3; end <= len(code) && end-i-2 <= 0xff; end++ {
+			if int(binary.BigEndian.Uint16(code[end-2:end])) != end-i-2 {
+				continue
+			}
+			if _, err := decodeCBORMap(code[i : end-2]); err == nil {
+				ranges = append(ranges, CodeRange{Start: i, Length: end - i})
+				i = end - 1
+				break
+			}
+		}
+	}
+	return ranges
+}
+
+// MaskCode returns a copy of the code with the ranges zeroed.
+func MaskCode(code []byte, ranges []CodeRange) []byte {
+	masked := append([]byte(nil), code...)
+	for _, r := range ranges {
+		for i := max(r.Start, 0); i < r.Start+r.Length && i < len(masked); i++ {
+			masked[i] = 0
+		}
+	}
+	return masked
+}
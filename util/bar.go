@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"sync"
 	"time"
@@ -15,12 +16,21 @@ type Bar struct {
 	current int
 	total   int
 	start   time.Time
+
+	// speed shows the speed and ETA, count is not capped by total for it
+	speed bool
+	first int
+	count int
+	// expected means total is the expected attempts of a random search, which can be passed
+	expected bool
 }
 
 func NewBar(current, total int) *Bar {
 	bar := new(Bar)
 	bar.current = current
 	bar.total = total
+	bar.first = current
+	bar.count = current
 	bar.start = time.Now()
 	if bar.graph == "" {
 		bar.graph = "█"
@@ -38,12 +48,44 @@ func NewBarWithGraph(start, total int, graph string) *Bar {
 	return bar
 }
 
+// WithSpeed shows the speed per second and the ETA, for the long running ones.
+func (bar *Bar) WithSpeed() *Bar {
+	bar.speed = true
+	return bar
+}
+
+// WithExpected treats total as the expected attempts of a random search, like the vanity ones. The
+// percent is the probability of a hit by now and the ETA is the expected time of a hit, it doesn't
+// change by the attempts done since each attempt is independent.
+func (bar *Bar) WithExpected() *Bar {
+	bar.expected = true
+	return bar
+}
+
 func (bar *Bar) getPercent() int {
+	if bar.expected {
+		// 1 - (1 - 1/total)^current, it never reaches 100% before the hit
+		return min(int((1-math.Exp(-float64(bar.current)/float64(bar.total)))*100), 99)
+	}
 	return int((float64(bar.current) / float64(bar.total)) * 100)
 }
 
-func (bar *Bar) getTime() (s string) {
-	u := time.Now().Sub(bar.start).Seconds()
+func (bar *Bar) getTime() string {
+	return formatDuration(time.Now().Sub(bar.start).Seconds())
+}
+
+func (bar *Bar) getSpeed() (speed float64, eta string) {
+	speed = float64(bar.count-bar.first) / time.Now().Sub(bar.start).Seconds()
+	if speed <= 0 {
+		return 0, "-"
+	}
+	if bar.expected {
+		return speed, formatDuration(float64(bar.total) / speed)
+	}
+	return speed, formatDuration(float64(bar.total-bar.current) / speed)
+}
+
+func formatDuration(u float64) (s string) {
 	h := int(u) / 3600
 	m := int(u) % 3600 / 60
 	if h > 0 {
@@ -63,22 +105,30 @@ func (bar *Bar) Load() {
 		bar.rate += bar.graph
 	}
 	fmt.Printf("\r[%-100s]% 3d%%    %2s   %d/%d", bar.rate, bar.percent, bar.getTime(), bar.current, bar.total)
+	if bar.speed {
+		speed, eta := bar.getSpeed()
+		fmt.Printf("   %.0f/s   ETA %s ", speed, eta)
+	}
 }
 
 func (bar *Bar) Reset(current int) {
 	bar.mu.Lock()
 	defer bar.mu.Unlock()
+	bar.count = current
 	bar.current = current
+	if !bar.expected {
+		bar.current = min(current, bar.total)
+	}
 	bar.Load()
-
 }
 
 func (bar *Bar) Add(i int) {
 	bar.mu.Lock()
 	defer bar.mu.Unlock()
+	bar.count += i
 	bar.current += i
-	if bar.current > bar.total {
-		bar.current = bar.total
+	if !bar.expected {
+		bar.current = min(bar.current, bar.total)
 	}
 	bar.Load()
 }
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+/* ------------------------- Contract addresses ------------------------- */
+
+// CREATE2 prefixes, TVM replaces the 0xff of EIP-1014 with the TRON address prefix
+const (
+	Create2PrefixETH  = 0xff
+	Create2PrefixTRON = 0x41
+)
+
+// CreateAddress is the Ethereum CREATE address, keccak256(rlp([deployer, nonce]))[12:].
+func CreateAddress(deployer []byte, nonce uint64) []byte {
+	return crypto.CreateAddress(common.BytesToAddress(deployer), nonce).Bytes()
+}
+
+// TronCreateAddress is the address of a contract deployed by the CreateSmartContract transaction,
+// keccak256(txid ‖ 0x41 ‖ owner)[12:].
+func TronCreateAddress(txid, owner []byte) []byte {
+	return crypto.Keccak256(txid, []byte{0x41}, owner)[12:]
+}
+
+// TronInternalCreateAddress is the address of a contract created by CREATE in TVM, the nonce is
+// the count of internal transactions before it in the root transaction,
+// keccak256(txid ‖ uint64(nonce))[12:].
+func TronInternalCreateAddress(txid []byte, nonce uint64) []byte {
+	return crypto.Keccak256(txid, binary.BigEndian.AppendUint64(nil, nonce))[12:]
+}
+
+// Create2Address is keccak256(prefix ‖ deployer ‖ salt ‖ initCodeHash)[12:].
+func Create2Address(prefix byte, deployer, salt, initCodeHash []byte) []byte {
+	return crypto.Keccak256([]byte{prefix}, deployer, salt, initCodeHash)[12:]
+}
+
+// ParseSalt accepts hex (left padded to 32 bytes like a uint256 salt) or a dec number.
+func ParseSalt(s string) ([]byte, error) {
+	if Has0xPrefix(s) {
+		data, ok := FromHex(s)
+		if !ok {
+			return nil, errors.New("salt is not valid hex")
+		}
+		if len(data) > 32 {
+			return nil, errors.New("salt is longer than 32 bytes")
+		}
+		return common.LeftPadBytes(data, 32), nil
+	}
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+		return nil, errors.New("salt should be hex or uint256 in dec")
+	}
+	return common.LeftPadBytes(n.Bytes(), 32), nil
+}
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+/* ------------------------- Disassembler ------------------------- */
+
+// Instruction is an opcode at PC, Undefined ones are kept as INVALID bytes.
+type Instruction struct {
+	PC        int
+	Op        vm.OpCode
+	Arg       []byte
+	Undefined bool
+	Truncated bool
+	// Target is the static target of JUMP/JUMPI pushed right before it, -1 if dynamic
+	Target int
+}
+
+// Block is a basic block of the instructions [Start, End], From has the pcs of static jumps to it.
+// Dead ones can't be reached.
+type Block struct {
+	Start int
+	End   int
+	From  []int
+	Dead  bool
+}
+
+// DispatchEntry is a function of the dispatcher, Entry is the pc it jumps to.
+type DispatchEntry struct {
+	ID    []byte
+	Entry int
+}
+
+type Disassembly struct {
+	Instructions []*Instruction
+	Blocks       []*Block
+	Jumpdests    map[int]bool
+	Selectors    []*DispatchEntry
+}
+
+// Disassemble sweeps the code linearly, undefined bytes don't stop it so the data after the code is
+// shown too. The defined func tells the opcodes of the VM, like the TVM ones.
+func Disassemble(code []byte, defined func(vm.OpCode) bool) *Disassembly {
+	d := &Disassembly{Jumpdests: make(map[int]bool)}
+	for pc := 0; pc < len(code); pc++ {
+		ins := &Instruction{PC: pc, Op: vm.OpCode(code[pc]), Target: -1}
+		switch {
+		case ins.Op.IsPush():
+			end := pc + 1 + int(ins.Op-vm.PUSH0)
+			if end > len(code) {
+				end, ins.Truncated = len(code), true
+			}
+			ins.Arg = code[pc+1 : end]
+			pc = end - 1
+		case ins.Op == vm.JUMPDEST:
+			d.Jumpdests[pc] = true
+		case !defined(ins.Op):
+			ins.Undefined = true
+		}
+		d.Instructions = append(d.Instructions, ins)
+	}
+	d.resolveJumps()
+	d.splitBlocks()
+	d.findSelectors()
+	return d
+}
+
+// resolveJumps sets the targets of the jumps whose target is pushed right before them.
+func (d *Disassembly) resolveJumps() {
+	for i, ins := range d.Instructions {
+		if i == 0 || (ins.Op != vm.JUMP && ins.Op != vm.JUMPI) {
+			continue
+		}
+		if push := d.Instructions[i-1]; push.Op.IsPush() && !push.Truncated {
+			if target := new(big.Int).SetBytes(push.Arg); target.IsInt64() && target.Int64() < 1<<32 {
+				ins.Target = int(target.Int64())
+			}
+		}
+	}
+}
+
+// splitBlocks starts a block at JUMPDEST and after the instructions ending the flow. The block
+// after a non-JUMPI end can't be reached unless it's a JUMPDEST, it's kept until the next JUMPDEST
+// as it's usually data.
+func (d *Disassembly) splitBlocks() {
+	index := make(map[int]*Block)
+	var block *Block
+	for i, ins := range d.Instructions {
+		if block == nil || ins.Op == vm.JUMPDEST {
+			dead := block == nil && i != 0 && ins.Op != vm.JUMPDEST && d.Instructions[i-1].Op != vm.JUMPI
+			block = &Block{Start: i, Dead: dead}
+			d.Blocks = append(d.Blocks, block)
+			index[ins.PC] = block
+		}
+		block.End = i
+		if endsBlock(ins) && !block.Dead {
+			block = nil
+		}
+	}
+	for _, ins := range d.Instructions {
+		if ins.Target >= 0 && d.Jumpdests[ins.Target] {
+			index[ins.Target].From = append(index[ins.Target].From, ins.PC)
+		}
+	}
+}
+
+func endsBlock(ins *Instruction) bool {
+	switch ins.Op {
+	case vm.STOP, vm.JUMP, vm.JUMPI, vm.RETURN, vm.REVERT, vm.INVALID, vm.SELFDESTRUCT:
+		return true
+	}
+	return ins.Undefined
+}
+
+// findSelectors matches the dispatcher of solc, `PUSH4 selector (DUP2) EQ PUSH entry JUMPI`. The
+// selectors with leading zero bytes are pushed by PUSH1 to PUSH3 after DUP1 (or before DUP2), they
+// are padded back to 4 bytes.
+func (d *Disassembly) findSelectors() {
+	seen := make(map[string]bool)
+	for i, ins := range d.Instructions {
+		if ins.Op < vm.PUSH1 || ins.Op > vm.PUSH4 || ins.Truncated || i+3 >= len(d.Instructions) {
+			continue
+		}
+		next := d.Instructions[i+1 : min(i+5, len(d.Instructions))]
+		dup := i > 0 && d.Instructions[i-1].Op == vm.DUP1
+		if next[0].Op == vm.DUP2 {
+			next, dup = next[1:], true
+		}
+		// the shorter pushes compare small constants too, they must compare the duplicated selector
+		if ins.Op != vm.PUSH4 && !dup {
+			continue
+		}
+		if len(next) < 3 || next[0].Op != vm.EQ || !next[1].Op.IsPush() || next[2].Op != vm.JUMPI || next[2].Target < 0 {
+			continue
+		}
+		id := common.LeftPadBytes(ins.Arg, 4)
+		if key := string(id); !seen[key] {
+			seen[key] = true
+			d.Selectors = append(d.Selectors, &DispatchEntry{ID: id, Entry: next[2].Target})
+		}
+	}
+}
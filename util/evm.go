@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"unsafe"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+/* ------------------------- Local EVM ------------------------- */
+
+// OpStub is an opcode added to the EVM, like the TVM ones. It pops Pops items and pushes the
+// result of Run, args are the popped items with the stack top first.
+type OpStub struct {
+	Op   vm.OpCode
+	Pops int
+	Gas  uint64
+	Run  func(evm *vm.EVM, scope *vm.ScopeContext, args []uint256.Int) uint256.Int
+}
+
+// RunCode runs the code with the input like runtime.Execute, with the stubs installed. The code is
+// at the address of runtime.Execute, cfg.State has the state after it.
+func RunCode(code, input []byte, cfg *runtime.Config, stubs []*OpStub) ([]byte, uint64, error) {
+	if err := setRunDefaults(cfg); err != nil {
+		return nil, 0, err
+	}
+	evm := runtime.NewEnv(cfg)
+	if cfg.EVMConfig.Tracer != nil {
+		// the storage and log hooks are called by the hooked state, like core.ApplyTransaction does
+		evm.StateDB = state.NewHookedState(cfg.State, cfg.EVMConfig.Tracer)
+	}
+	if err := InstallOpStubs(evm, stubs); err != nil {
+		return nil, 0, err
+	}
+	address := common.BytesToAddress([]byte("contract"))
+	rules := cfg.ChainConfig.Rules(evm.Context.BlockNumber, evm.Context.Random != nil, evm.Context.Time)
+	cfg.State.Prepare(rules, cfg.Origin, cfg.Coinbase, &address, vm.ActivePrecompiles(rules), nil)
+	cfg.State.CreateAccount(address)
+	cfg.State.SetCode(address, code, tracing.CodeChangeUnspecified)
+	value := uint256.MustFromBig(cfg.Value)
+	cfg.State.AddBalance(cfg.Origin, value, tracing.BalanceChangeUnspecified)
+	ret, leftOverGas, err := evm.Call(cfg.Origin, address, input, cfg.GasLimit, value)
+	return ret, cfg.GasLimit - leftOverGas, err
+}
+
+// setRunDefaults fills the unset fields of cfg like runtime.Execute does, the chain has all forks
+// up to Cancun at genesis and the state is empty.
+func setRunDefaults(cfg *runtime.Config) error {
+	if cfg.ChainConfig == nil {
+		zero := uint64(0)
+		cfg.ChainConfig = &params.ChainConfig{
+			ChainID:                 big.NewInt(1),
+			HomesteadBlock:          new(big.Int),
+			EIP150Block:             new(big.Int),
+			EIP155Block:             new(big.Int),
+			EIP158Block:             new(big.Int),
+			ByzantiumBlock:          new(big.Int),
+			ConstantinopleBlock:     new(big.Int),
+			PetersburgBlock:         new(big.Int),
+			IstanbulBlock:           new(big.Int),
+			MuirGlacierBlock:        new(big.Int),
+			BerlinBlock:             new(big.Int),
+			LondonBlock:             new(big.Int),
+			TerminalTotalDifficulty: new(big.Int),
+			ShanghaiTime:            &zero,
+			CancunTime:              &zero,
+		}
+	}
+	if cfg.Difficulty == nil {
+		cfg.Difficulty = new(big.Int)
+	}
+	if cfg.GasLimit == 0 {
+		cfg.GasLimit = math.MaxUint64
+	}
+	if cfg.GasPrice == nil {
+		cfg.GasPrice = new(big.Int)
+	}
+	if cfg.Value == nil {
+		cfg.Value = new(big.Int)
+	}
+	if cfg.BlockNumber == nil {
+		cfg.BlockNumber = new(big.Int)
+	}
+	if cfg.GetHashFn == nil {
+		cfg.GetHashFn = func(n uint64) common.Hash {
+			return crypto.Keccak256Hash([]byte(new(big.Int).SetUint64(n).String()))
+		}
+	}
+	if cfg.BaseFee == nil {
+		cfg.BaseFee = big.NewInt(params.InitialBaseFee)
+	}
+	if cfg.BlobBaseFee == nil {
+		cfg.BlobBaseFee = big.NewInt(params.BlobTxMinBlobGasprice)
+	}
+	if cfg.Random == nil {
+		cfg.Random = new(common.Hash)
+	}
+	if cfg.State == nil {
+		statedb, err := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+		if err != nil {
+			return err
+		}
+		cfg.State = statedb
+	}
+	return nil
+}
+
+// InstallOpStubs adds the stubs to the jump table of the EVM. go-ethereum doesn't export the jump
+// table and its operations, so they are set by reflection, it fails if the pinned version changes them.
+func InstallOpStubs(evm *vm.EVM, stubs []*OpStub) error {
+	if len(stubs) == 0 {
+		return nil
+	}
+	field := reflect.ValueOf(evm).Elem().FieldByName("table")
+	if !field.IsValid() || field.Type() != reflect.TypeOf((*vm.JumpTable)(nil)) {
+		return errors.New("the jump table of go-ethereum vm.EVM is not found")
+	}
+	stackField, ok := reflect.TypeOf(vm.Stack{}).FieldByName("data")
+	if !ok || stackField.Type != reflect.TypeOf([]uint256.Int(nil)) {
+		return errors.New("the stack data of go-ethereum vm.Stack is not found")
+	}
+	tableField := (**vm.JumpTable)(unsafe.Pointer(field.UnsafeAddr()))
+
+	// the table is shared by all EVMs of the fork, it's copied before changed
+	table := **tableField
+	opType := reflect.TypeOf(table[vm.STOP]).Elem()
+	for _, stub := range stubs {
+		op := reflect.New(opType)
+		fields := map[string]any{
+			"execute":     stub.execute,
+			"constantGas": stub.Gas,
+			"minStack":    stub.Pops,
+			"maxStack":    int(params.StackLimit) + stub.Pops - 1,
+		}
+		for name, value := range fields {
+			f := op.Elem().FieldByName(name)
+			v := reflect.ValueOf(value)
+			if !f.IsValid() || !v.Type().ConvertibleTo(f.Type()) {
+				return errors.New("the operation of go-ethereum vm.JumpTable has no field " + name)
+			}
+			reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem().Set(v.Convert(f.Type()))
+		}
+		reflect.ValueOf(&table).Elem().Index(int(stub.Op)).Set(op)
+	}
+	*tableField = &table
+	return nil
+}
+
+func (s *OpStub) execute(pc *uint64, evm *vm.EVM, scope *vm.ScopeContext) ([]byte, error) {
+	// push and pop of vm.Stack are not exported, the data is changed directly
+	data := (*[]uint256.Int)(unsafe.Pointer(reflect.ValueOf(scope.Stack).Elem().FieldByName("data").UnsafeAddr()))
+	args := make([]uint256.Int, s.Pops)
+	for i := range args {
+		args[i] = (*data)[len(*data)-1-i]
+	}
+	*data = append((*data)[:len(*data)-s.Pops], s.Run(evm, scope, args))
+	return nil, nil
+}
@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+/* ------------------------- ABI layout guessing ------------------------- */
+
+const (
+	RoleOffset  = "offset"
+	RoleLength  = "length"
+	RoleElement = "element"
+	RoleValue   = "value"
+	RoleData    = "data"
+)
+
+// WordGuess is the guessed role and value of a 32 bytes word in ABI encoded data.
+type WordGuess struct {
+	Offset int
+	Word   []byte
+	Role   string
+	Type   string
+	Note   string
+}
+
+// GuessLayout infers a plausible layout of ABI encoded data (selector excluded): head words pointing
+// to a length word in the tail are offsets, the tail is bytes/string if its padding is zero,
+// otherwise an array whose elements are guessed the same way. Other words are guessed by GuessWord.
+func GuessLayout(data []byte) ([]*WordGuess, error) {
+	if len(data)%32 != 0 {
+		return nil, errors.New("data must be 32*N")
+	}
+	guesses := make([]*WordGuess, len(data)/32)
+	for i := range guesses {
+		word := data[i*32 : i*32+32]
+		ty, note := GuessWord(word)
+		guesses[i] = &WordGuess{Offset: i * 32, Word: word, Role: RoleValue, Type: ty, Note: note}
+	}
+	guessTuple(data, guesses, 0, len(data), RoleValue)
+	return guesses, nil
+}
+
+// guessTuple guesses the encoded tuple in data[base:end], role is for the static head words
+func guessTuple(data []byte, guesses []*WordGuess, base, end int, role string) {
+	headEnd := end
+	for i := base; i < headEnd; i += 32 {
+		if target, ok := offsetTarget(data, base, i, end); ok && target < headEnd {
+			headEnd = target
+		}
+	}
+	var targets []int
+	for i := base; i < headEnd; i += 32 {
+		guesses[i/32].Role = role
+		if target, ok := offsetTarget(data, base, i, end); ok && target >= headEnd {
+			guesses[i/32].Role = RoleOffset
+			guesses[i/32].Type = ""
+			guesses[i/32].Note = fmt.Sprintf("-> 0x%x", target)
+			targets = append(targets, target)
+		}
+	}
+	sort.Ints(targets)
+	for i, target := range targets {
+		if i > 0 && target == targets[i-1] {
+			continue
+		}
+		regionEnd := end
+		for _, next := range targets[i+1:] {
+			if next > target {
+				regionEnd = next
+				break
+			}
+		}
+		guessDynamic(data, guesses, target, regionEnd)
+	}
+}
+
+// guessDynamic guesses the bytes/string or array starting with the length word at data[start]
+func guessDynamic(data []byte, guesses []*WordGuess, start, end int) {
+	length := new(big.Int).SetBytes(data[start : start+32]).Uint64()
+	guesses[start/32].Role = RoleLength
+	guesses[start/32].Type = ""
+	guesses[start/32].Note = fmt.Sprintf("%d", length)
+	body := data[start+32 : end]
+	words := int(length+31) / 32
+	// bytes fills the region exactly, unless it can not be an array
+	fits := words*32 <= len(body) && allZeroBytes(body[length:])
+	if fits && (words*32 == len(body) || int(length)*32 > len(body)) {
+		text := body[:length]
+		ty := "bytes"
+		if utf8.Valid(text) && isPrintable(text) {
+			ty = "string"
+		}
+		for i := 0; i < words; i++ {
+			g := guesses[(start+32)/32+i]
+			chunk := text[i*32 : min((i+1)*32, len(text))]
+			g.Role, g.Type = RoleData, ty
+			if ty == "string" {
+				g.Note = fmt.Sprintf("%q", chunk)
+			} else {
+				g.Note = fmt.Sprintf("%#x", chunk)
+			}
+		}
+		return
+	}
+	if int(length)*32 <= len(body) {
+		guessTuple(data, guesses, start+32, end, RoleElement)
+	}
+}
+
+// offsetTarget returns the absolute position the word at data[i] points to if it looks like an offset
+func offsetTarget(data []byte, base, i, end int) (int, bool) {
+	value := new(big.Int).SetBytes(data[i : i+32])
+	if !value.IsUint64() || value.Uint64()%32 != 0 || value.Uint64() == 0 {
+		return 0, false
+	}
+	target := base + int(value.Uint64())
+	if target <= i || target+32 > end {
+		return 0, false
+	}
+	length := new(big.Int).SetBytes(data[target : target+32])
+	if !length.IsUint64() || length.Uint64() > uint64(end) {
+		return 0, false
+	}
+	// the tail must be able to hold it, either as bytes or as static array
+	if target+32+int(length.Uint64()+31)/32*32 > end {
+		return 0, false
+	}
+	return target, true
+}
+
+// GuessWord guesses the type of a single word: address (left-padded 20 or 21 bytes), small int, bool,
+// negative int, right-padded bytesN (maybe ASCII), otherwise uint256.
+func GuessWord(word []byte) (ty string, note string) {
+	num := new(big.Int).SetBytes(word)
+	leading := 0
+	for leading < len(word) && word[leading] == 0 {
+		leading++
+	}
+	trailing := 0
+	for trailing < len(word) && word[len(word)-1-trailing] == 0 {
+		trailing++
+	}
+	switch {
+	case num.Sign() == 0:
+		return "uint256", "0 (or false)"
+	case num.Cmp(big.NewInt(1)) == 0:
+		return "bool", "1 (or true)"
+	case leading == 11 && word[11] == 0x41:
+		// TRON address with the 0x41 prefix
+		return "address", ToBase58(word[11:])
+	case leading >= 12 && leading <= 15:
+		// left-padded 20 bytes with few leading zero bytes in the address itself
+		return "address", ToBase58(word[12:])
+	case num.BitLen() <= 64:
+		return "uint256", num.String()
+	case leading == 0 && word[0] == 0xff && bytes.Count(word[:8], []byte{0xff}) == 8:
+		neg := new(big.Int).Sub(num, new(big.Int).Lsh(big.NewInt(1), 256))
+		return "int256", neg.String()
+	case trailing >= 4 && leading == 0:
+		size := 32 - trailing
+		data := word[:size]
+		if utf8.Valid(data) && isPrintable(data) {
+			return fmt.Sprintf("bytes%d", size), fmt.Sprintf("%#x %q", data, data)
+		}
+		return fmt.Sprintf("bytes%d", size), fmt.Sprintf("%#x", data)
+	}
+	return "uint256", common.BytesToHash(word).Hex()
+}
+
+func isPrintable(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+	for _, c := range data {
+		if (c < 0x20 || c > 0x7e) && c != '\n' && c != '\t' && c < 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+func allZeroBytes(data []byte) bool {
+	for _, c := range data {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
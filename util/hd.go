@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/tyler-smith/go-bip39"
+)
+
+/* ------------------------- BIP39 & BIP32/BIP44 ------------------------- */
+
+// SLIP-44 coin types
+const (
+	CoinTRON = 195
+	CoinETH  = 60
+)
+
+// NewMnemonic generates a BIP39 english mnemonic of 12, 15, 18, 21 or 24 words.
+func NewMnemonic(words int) (string, error) {
+	if words < 12 || words > 24 || words%3 != 0 {
+		return "", fmt.Errorf("mnemonic should have 12, 15, 18, 21 or 24 words")
+	}
+	entropy, err := bip39.NewEntropy(words / 3 * 32)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// MnemonicSeed checks the mnemonic checksum and returns the seed with the optional passphrase.
+func MnemonicSeed(mnemonic, passphrase string) ([]byte, error) {
+	return bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+}
+
+// AccountKey derives the BIP44 account key m/44'/coin'/account' from the seed.
+func AccountKey(seed []byte, coin, account uint32) (*hdkeychain.ExtendedKey, error) {
+	key, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range []uint32{44, coin, account} {
+		if key, err = key.Derive(hdkeychain.HardenedKeyStart + index); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// ParseExtendedKey parses the xpub (or xprv) of an account for watch-only derivation.
+func ParseExtendedKey(key string) (*hdkeychain.ExtendedKey, error) {
+	return hdkeychain.NewKeyFromString(key)
+}
+
+// DerivedKey is the key at <account>/0/index, Private is nil if derived from xpub.
+type DerivedKey struct {
+	Index   uint32
+	Public  *ecdsa.PublicKey
+	Private *ecdsa.PrivateKey
+}
+
+// DeriveAddressKeys derives the external chain keys <account>/0/i for i in [start, start+count),
+// the range must be in the non-hardened indexes.
+func DeriveAddressKeys(account *hdkeychain.ExtendedKey, start, count uint64) ([]*DerivedKey, error) {
+	if count > hdkeychain.HardenedKeyStart || start > hdkeychain.HardenedKeyStart-count {
+		return nil, fmt.Errorf("start+count should be at most %d, the non-hardened indexes", hdkeychain.HardenedKeyStart)
+	}
+	external, err := account.Derive(0)
+	if err != nil {
+		return nil, err
+	}
+	var keys []*DerivedKey
+	for i := uint32(start); i < uint32(start+count); i++ {
+		child, err := external.Derive(i)
+		if err != nil {
+			return nil, err
+		}
+		pub, err := child.ECPubKey()
+		if err != nil {
+			return nil, err
+		}
+		key := &DerivedKey{Index: i, Public: pub.ToECDSA()}
+		if child.IsPrivate() {
+			priv, err := child.ECPrivKey()
+			if err != nil {
+				return nil, err
+			}
+			key.Private = priv.ToECDSA()
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
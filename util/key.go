@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"golang.org/x/term"
+)
+
+/* ------------------------- Key sources ------------------------- */
+
+// KeySource tells where the private key comes from, the first one set is used: Env is the name of
+// the variable having the hex key, File has the hex key (`-` for stdin), Keystore is a Web3 Secret
+// Storage V3 file (scrypt or pbkdf2) whose password is in PasswordFile or prompted.
+// If none is set, the hex key is prompted without echo.
+type KeySource struct {
+	Env          string
+	File         string
+	Keystore     string
+	PasswordFile string
+}
+
+func (s *KeySource) IsSet() bool {
+	return len(s.Env) != 0 || len(s.File) != 0 || len(s.Keystore) != 0
+}
+
+func (s *KeySource) Load() (*ecdsa.PrivateKey, error) {
+	switch {
+	case len(s.Env) != 0:
+		text, ok := os.LookupEnv(s.Env)
+		if !ok {
+			return nil, fmt.Errorf("env %s is not set", s.Env)
+		}
+		return ParsePrivateKey(text)
+	case len(s.File) != 0:
+		if s.File == "-" {
+			text, err := readLine()
+			if err != nil {
+				return nil, err
+			}
+			return ParsePrivateKey(text)
+		}
+		data, err := os.ReadFile(s.File)
+		if err != nil {
+			return nil, err
+		}
+		return ParsePrivateKey(string(data))
+	case len(s.Keystore) != 0:
+		data, err := os.ReadFile(s.Keystore)
+		if err != nil {
+			return nil, err
+		}
+		password, err := s.password()
+		if err != nil {
+			return nil, err
+		}
+		key, err := keystore.DecryptKey(data, password)
+		if err != nil {
+			return nil, err
+		}
+		return key.PrivateKey, nil
+	}
+	text, err := ReadPassword("Private key: ")
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKey(text)
+}
+
+func (s *KeySource) password() (string, error) {
+	if len(s.PasswordFile) != 0 {
+		data, err := os.ReadFile(s.PasswordFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	return ReadPassword("Password: ")
+}
+
+// ParsePrivateKey parses the 32 bytes hex key, the 0x prefix is optional.
+func ParsePrivateKey(text string) (*ecdsa.PrivateKey, error) {
+	text = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(text), "0x"), "0X")
+	if len(text) != 64 {
+		return nil, errors.New("private-key must be 32 bytes in hex format")
+	}
+	return crypto.HexToECDSA(text)
+}
+
+// ReadPassword prompts on stderr and reads without echo, or reads a line if stdin is not a terminal.
+func ReadPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return readLine()
+	}
+	data, err := term.ReadPassword(int(os.Stdin.Fd()))
+	return string(data), err
+}
+
+// ReadNewPassword prompts the password twice, they must be the same.
+func ReadNewPassword() (string, error) {
+	password, err := ReadPassword("New password: ")
+	if err != nil {
+		return "", err
+	}
+	confirm, err := ReadPassword("Repeat password: ")
+	if err != nil {
+		return "", err
+	}
+	if password != confirm {
+		return "", errors.New("passwords do not match")
+	}
+	return password, nil
+}
+
+// DefaultKeystoreDir is the user config dir, like ~/.config/tt/keystore on linux.
+func DefaultKeystoreDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tt", "keystore"), nil
+}
+
+// WriteKeystore encrypts the key into a V3 keystore file in dir, named like geth does.
+// Light uses the light scrypt params, faster but weaker.
+func WriteKeystore(dir string, key *ecdsa.PrivateKey, password string, light bool) (string, error) {
+	n, p := keystore.StandardScryptN, keystore.StandardScryptP
+	if light {
+		n, p = keystore.LightScryptN, keystore.LightScryptP
+	}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return "", err
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	data, err := keystore.EncryptKey(&keystore.Key{Id: id, Address: address, PrivateKey: key}, password, n, p)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	name := fmt.Sprintf("UTC--%s--%x", strings.ReplaceAll(now.Format("2006-01-02T15-04-05.000000000Z07:00"), ":", "-"), address)
+	path := filepath.Join(dir, name)
+	// the key file is never overwritten
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return "", err
+	}
+	return path, file.Close()
+}
+
+// stdinReader is shared, a new reader may buffer the lines of later reads
+var stdinReader = bufio.NewReader(os.Stdin)
+
+func readLine() (string, error) {
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && len(line) == 0 {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+/* ------------------------- Solc storage layout ------------------------- */
+
+// StorageLayout is the `storageLayout` output of solc.
+type StorageLayout struct {
+	Storage []StorageItem           `json:"storage"`
+	Types   map[string]*StorageType `json:"types"`
+}
+
+type StorageItem struct {
+	Label  string `json:"label"`
+	Offset int    `json:"offset"`
+	Slot   string `json:"slot"`
+	Type   string `json:"type"`
+}
+
+type StorageType struct {
+	Encoding      string        `json:"encoding"`
+	Label         string        `json:"label"`
+	NumberOfBytes string        `json:"numberOfBytes"`
+	Key           string        `json:"key,omitempty"`
+	Value         string        `json:"value,omitempty"`
+	Base          string        `json:"base,omitempty"`
+	Members       []StorageItem `json:"members,omitempty"`
+}
+
+// SlotLocation is where a variable lives: Offset is the byte offset from the right of the slot.
+type SlotLocation struct {
+	Slot   common.Hash
+	Offset int
+	Size   int
+	Type   *StorageType
+}
+
+// LoadStorageLayout accepts the layout itself, or any json having it in the `storageLayout` field.
+func LoadStorageLayout(data []byte) (*StorageLayout, error) {
+	var wrapper struct {
+		StorageLayout *StorageLayout `json:"storageLayout"`
+	}
+	if err := json.Unmarshal(data, &wrapper); err != nil {
+		return nil, err
+	}
+	layout := wrapper.StorageLayout
+	if layout == nil {
+		layout = new(StorageLayout)
+		if err := json.Unmarshal(data, layout); err != nil {
+			return nil, err
+		}
+	}
+	if len(layout.Storage) == 0 {
+		return nil, fmt.Errorf("no storage variables in the layout")
+	}
+	return layout, nil
+}
+
+// Resolve locates the variable path like `balances[T...].amount`, `list[2]` or `owner`.
+// Mapping keys are encoded by the key type of the layout, array indexes are plain numbers.
+func (l *StorageLayout) Resolve(path string) (*SlotLocation, error) {
+	path = strings.TrimSpace(path)
+	end := strings.IndexAny(path, ".[")
+	if end < 0 {
+		end = len(path)
+	}
+	label := path[:end]
+	var loc *SlotLocation
+	for _, item := range l.Storage {
+		if item.Label == label {
+			var err error
+			if loc, err = l.locate(common.Hash{}, item); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if loc == nil {
+		return nil, fmt.Errorf("variable `%s` not found in the layout", label)
+	}
+	rest := path[end:]
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			member, err := l.member(loc, rest[1:end+1])
+			if err != nil {
+				return nil, err
+			}
+			loc = member
+			rest = rest[end+1:]
+		case '[':
+			inner, err := takeUntilMatchingBracket(rest[1:])
+			if err != nil {
+				return nil, err
+			}
+			if loc, err = l.index(loc, strings.TrimSpace(inner)); err != nil {
+				return nil, err
+			}
+			rest = rest[len(inner)+2:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in variable path", rest)
+		}
+	}
+	return loc, nil
+}
+
+// locate returns the location of item relative to the base slot
+func (l *StorageLayout) locate(base common.Hash, item StorageItem) (*SlotLocation, error) {
+	ty, err := l.typeOf(item.Type)
+	if err != nil {
+		return nil, err
+	}
+	offset, ok := math.ParseBig256(item.Slot)
+	if !ok {
+		return nil, fmt.Errorf("invalid slot %q of `%s`", item.Slot, item.Label)
+	}
+	return &SlotLocation{Slot: AddSlot(base, offset), Offset: item.Offset, Size: ty.size(), Type: ty}, nil
+}
+
+func (l *StorageLayout) typeOf(id string) (*StorageType, error) {
+	ty, ok := l.Types[id]
+	if !ok {
+		return nil, fmt.Errorf("type %s not found in the layout", id)
+	}
+	return ty, nil
+}
+
+func (l *StorageLayout) member(loc *SlotLocation, name string) (*SlotLocation, error) {
+	if len(loc.Type.Members) == 0 {
+		return nil, fmt.Errorf("`%s` is not a struct, can not access member `%s`", loc.Type.Label, name)
+	}
+	for _, member := range loc.Type.Members {
+		if member.Label == name {
+			return l.locate(loc.Slot, member)
+		}
+	}
+	return nil, fmt.Errorf("member `%s` not found in `%s`", name, loc.Type.Label)
+}
+
+func (l *StorageLayout) index(loc *SlotLocation, key string) (*SlotLocation, error) {
+	switch {
+	case loc.Type.Encoding == "mapping":
+		keyType, err := l.typeOf(loc.Type.Key)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := EncodeMappingKey(mappingKeyType(keyType.Label) + ":" + key)
+		if err != nil {
+			return nil, err
+		}
+		valueType, err := l.typeOf(loc.Type.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &SlotLocation{Slot: MappingSlot(encoded, loc.Slot), Size: valueType.size(), Type: valueType}, nil
+	case loc.Type.Encoding == "dynamic_array" || (loc.Type.Encoding == "inplace" && len(loc.Type.Base) != 0):
+		index, err := strconv.ParseUint(key, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid array index: %q", key)
+		}
+		baseType, err := l.typeOf(loc.Type.Base)
+		if err != nil {
+			return nil, err
+		}
+		start := loc.Slot
+		if loc.Type.Encoding == "dynamic_array" {
+			start = crypto.Keccak256Hash(loc.Slot.Bytes())
+		}
+		// elements smaller than 16 bytes are packed into a slot, others take whole slots
+		size := uint64(baseType.size())
+		elem := &SlotLocation{Size: int(size), Type: baseType}
+		if size <= 16 {
+			perSlot := 32 / size
+			elem.Slot = AddSlot(start, new(big.Int).SetUint64(index/perSlot))
+			elem.Offset = int(index % perSlot * size)
+		} else {
+			elem.Slot = AddSlot(start, new(big.Int).SetUint64(index*((size+31)/32)))
+		}
+		return elem, nil
+	}
+	return nil, fmt.Errorf("`%s` is neither a mapping nor an array", loc.Type.Label)
+}
+
+func (t *StorageType) size() int {
+	size, _ := strconv.Atoi(t.NumberOfBytes)
+	return size
+}
+
+// mappingKeyType converts the solc type label to the type of EncodeMappingKey
+func mappingKeyType(label string) string {
+	switch {
+	case strings.HasPrefix(label, "address"), strings.HasPrefix(label, "contract "):
+		return "address"
+	case strings.HasPrefix(label, "enum "):
+		return "uint8"
+	}
+	return label
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+/* ------------------------- Personal messages ------------------------- */
+
+const (
+	// MessageTIP191 is `signMessageV2` of TronWeb, the prefix has the message length
+	MessageTIP191 = "tip191"
+	// MessageTIP191V1 is `trx.sign` of TronWeb for hex messages, the length is always 32
+	MessageTIP191V1 = "tip191-v1"
+	// MessageEIP191 is `personal_sign` of Ethereum wallets
+	MessageEIP191 = "eip191"
+)
+
+var MessageSchemes = []string{MessageTIP191, MessageTIP191V1, MessageEIP191}
+
+// ParseMessage takes 0x prefixed hex as bytes, otherwise the text itself.
+func ParseMessage(message string) []byte {
+	if Has0xPrefix(message) {
+		if data, ok := FromHex(message); ok {
+			return data
+		}
+	}
+	return []byte(message)
+}
+
+// MessagePrefix returns the prefix put before the message by the scheme.
+func MessagePrefix(scheme string, message []byte) (string, error) {
+	switch scheme {
+	case MessageTIP191:
+		return "\x19TRON Signed Message:\n" + strconv.Itoa(len(message)), nil
+	case MessageTIP191V1:
+		return "\x19TRON Signed Message:\n32", nil
+	case MessageEIP191:
+		return "\x19Ethereum Signed Message:\n" + strconv.Itoa(len(message)), nil
+	}
+	return "", fmt.Errorf("unknown message scheme %s, should be one of %v", scheme, MessageSchemes)
+}
+
+// HashMessage is keccak256(prefix ‖ message), the digest signed by wallets.
+func HashMessage(scheme string, message []byte) ([]byte, error) {
+	prefix, err := MessagePrefix(scheme, message)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256([]byte(prefix), message), nil
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+)
+
+/* ------------------------- Solidity metadata ------------------------- */
+
+// CodeMetadata is the CBOR trailer appended by solc, code ‖ cbor ‖ uint16(len(cbor)).
+type CodeMetadata struct {
+	Raw    []byte
+	Fields map[string]any
+}
+
+// SplitMetadata splits the runtime code and the metadata trailer, meta is nil if there is none.
+func SplitMetadata(code []byte) (runtime []byte, meta *CodeMetadata) {
+	if len(code) < 2 {
+		return code, nil
+	}
+	size := int(binary.BigEndian.Uint16(code[len(code)-2:]))
+	start := len(code) - 2 - size
+	if size == 0 || start < 0 {
+		return code, nil
+	}
+	fields, err := decodeCBORMap(code[start : len(code)-2])
+	if err != nil {
+		return code, nil
+	}
+	return code[:start], &CodeMetadata{Raw: code[start:], Fields: fields}
+}
+
+// String formats the known fields: solc version, IPFS (base58) or Swarm hashes, experimental.
+func (m *CodeMetadata) String() string {
+	var keys []string
+	for key := range m.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		value := m.Fields[key]
+		switch v := value.(type) {
+		case []byte:
+			switch {
+			case key == "solc" && len(v) == 3:
+				value = fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+			case key == "ipfs":
+				value = base58.Encode(v)
+			default:
+				value = fmt.Sprintf("0x%x", v)
+			}
+		}
+		parts = append(parts, fmt.Sprintf("%s: %v", key, value))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// decodeCBORMap decodes the map of text keys to bytes, text, bool or uint values, which is all solc emits.
+func decodeCBORMap(data []byte) (map[string]any, error) {
+	if len(data) == 0 || data[0]>>5 != 5 {
+		return nil, errors.New("not a CBOR map")
+	}
+	count, rest, err := cborHead(data)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]any)
+	for i := uint64(0); i < count; i++ {
+		var key, value any
+		if key, rest, err = cborItem(rest); err != nil {
+			return nil, err
+		}
+		text, ok := key.(string)
+		if !ok {
+			return nil, errors.New("CBOR map key is not text")
+		}
+		if value, rest, err = cborItem(rest); err != nil {
+			return nil, err
+		}
+		fields[text] = value
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing bytes after CBOR map")
+	}
+	return fields, nil
+}
+
+func cborItem(data []byte) (any, []byte, error) {
+	if len(data) == 0 {
+		return nil, nil, errors.New("unexpected end of CBOR")
+	}
+	major, minor := data[0]>>5, data[0]&0x1f
+	if major == 7 {
+		switch minor {
+		case 20:
+			return false, data[1:], nil
+		case 21:
+			return true, data[1:], nil
+		}
+		return nil, nil, fmt.Errorf("unsupported CBOR simple value %d", minor)
+	}
+	arg, rest, err := cborHead(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch major {
+	case 0:
+		return arg, rest, nil
+	case 2, 3:
+		if uint64(len(rest)) < arg {
+			return nil, nil, errors.New("unexpected end of CBOR")
+		}
+		if major == 3 {
+			return string(rest[:arg]), rest[arg:], nil
+		}
+		return rest[:arg], rest[arg:], nil
+	}
+	return nil, nil, fmt.Errorf("unsupported CBOR major type %d", major)
+}
+
+// cborHead returns the argument of the head, the length or value.
+func cborHead(data []byte) (uint64, []byte, error) {
+	minor := data[0] & 0x1f
+	if minor < 24 {
+		return uint64(minor), data[1:], nil
+	}
+	if minor > 27 {
+		return 0, nil, errors.New("indefinite CBOR length is not supported")
+	}
+	size := 1 << (minor - 24)
+	if len(data) < 1+size {
+		return 0, nil, errors.New("unexpected end of CBOR")
+	}
+	var arg uint64
+	for _, b := range data[1 : 1+size] {
+		arg = arg<<8 | uint64(b)
+	}
+	return arg, data[1+size:], nil
+}
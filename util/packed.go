@@ -0,0 +1,283 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+/* ------------------------- Params & packed encoding ------------------------- */
+
+// parseTypesInput accepts a signature `f(address,uint256)`, a function definition,
+// or only the types `address,uint256` (optionally in parentheses).
+func parseTypesInput(input string) (name string, types []string, err error) {
+	in := strings.TrimSpace(input)
+	if strings.Contains(in, "function") || sigRe.MatchString(in) {
+		return parseFunctionLikeInput(in)
+	}
+	if strings.HasPrefix(in, "(") && strings.HasSuffix(in, ")") {
+		in = in[1 : len(in)-1]
+	}
+	return parseSignature("f(" + in + ")")
+}
+
+// convertParams builds the method and converts each param by its type
+func convertParams(name string, typeList []string, params []string) (abi.Method, []any, error) {
+	if len(typeList) != len(params) {
+		return abi.Method{}, nil, fmt.Errorf("param count mismatch: signature expects %d args, got %d", len(typeList), len(params))
+	}
+	parsedABI, err := abi.JSON(strings.NewReader(buildSingleFunctionABIJSON(name, typeList)))
+	if err != nil {
+		return abi.Method{}, nil, fmt.Errorf("abi.JSON parse failed: %w", err)
+	}
+	method := parsedABI.Methods[name]
+	args := make([]any, len(params))
+	for i := range params {
+		v, err := convertStringToABIValue(method.Inputs[i].Type, params[i])
+		if err != nil {
+			return abi.Method{}, nil, fmt.Errorf("convert arg[%d] (%s) failed: %w", i, method.Inputs[i].Type.String(), err)
+		}
+		args[i] = v
+	}
+	return method, args, nil
+}
+
+// EncodeParams is the standard ABI encoding of params without selector, like abi.encode.
+func EncodeParams(input string, params []string) ([]byte, error) {
+	name, typeList, err := parseTypesInput(input)
+	if err != nil {
+		return nil, err
+	}
+	method, args, err := convertParams(name, typeList, params)
+	if err != nil {
+		return nil, err
+	}
+	data, err := method.Inputs.Pack(args...)
+	if err != nil {
+		return nil, fmt.Errorf("abi.Pack failed: %w", err)
+	}
+	return data, nil
+}
+
+// EncodePacked is abi.encodePacked: static types take their own size, bytes and string have
+// no length and padding, array elements are padded to 32 bytes. Tuples are not supported.
+func EncodePacked(input string, params []string) ([]byte, error) {
+	name, typeList, err := parseTypesInput(input)
+	if err != nil {
+		return nil, err
+	}
+	method, args, err := convertParams(name, typeList, params)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	for i, arg := range args {
+		packed, err := packValue(method.Inputs[i].Type, reflect.ValueOf(arg), false)
+		if err != nil {
+			return nil, fmt.Errorf("pack arg[%d] (%s) failed: %w", i, method.Inputs[i].Type.String(), err)
+		}
+		data = append(data, packed...)
+	}
+	return data, nil
+}
+
+func packValue(t abi.Type, v reflect.Value, inArray bool) ([]byte, error) {
+	switch t.T {
+	case abi.SliceTy, abi.ArrayTy:
+		if t.Elem.T == abi.SliceTy || t.Elem.T == abi.ArrayTy || t.Elem.T == abi.StringTy || t.Elem.T == abi.BytesTy || t.Elem.T == abi.TupleTy {
+			return nil, fmt.Errorf("encodePacked does not support %s", t.String())
+		}
+		var data []byte
+		for i := 0; i < v.Len(); i++ {
+			elem, err := packValue(*t.Elem, v.Index(i), true)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, elem...)
+		}
+		return data, nil
+	case abi.StringTy:
+		return []byte(v.String()), nil
+	case abi.BytesTy:
+		return v.Bytes(), nil
+	case abi.FixedBytesTy:
+		data := make([]byte, t.Size)
+		reflect.Copy(reflect.ValueOf(data), v)
+		if inArray {
+			return common.RightPadBytes(data, 32), nil
+		}
+		return data, nil
+	case abi.AddressTy:
+		addr := v.Interface().(common.Address)
+		if inArray {
+			return common.LeftPadBytes(addr.Bytes(), 32), nil
+		}
+		return addr.Bytes(), nil
+	case abi.BoolTy:
+		data := []byte{0}
+		if v.Bool() {
+			data[0] = 1
+		}
+		if inArray {
+			return common.LeftPadBytes(data, 32), nil
+		}
+		return data, nil
+	case abi.IntTy, abi.UintTy:
+		num, ok := v.Interface().(*big.Int)
+		if !ok {
+			// int8..int64 and uint8..uint64 are native go ints
+			if v.CanInt() {
+				num = big.NewInt(v.Int())
+			} else {
+				num = new(big.Int).SetUint64(v.Uint())
+			}
+		}
+		if err := checkIntRange(t, num); err != nil {
+			return nil, err
+		}
+		// two's complement, the sign is extended to the whole word
+		word := math.U256Bytes(new(big.Int).Set(num))
+		if inArray {
+			return word, nil
+		}
+		return word[32-t.Size/8:], nil
+	}
+	return nil, fmt.Errorf("encodePacked does not support %s", t.String())
+}
+
+func checkIntRange(t abi.Type, num *big.Int) error {
+	if t.T == abi.UintTy {
+		if num.Sign() < 0 || num.BitLen() > t.Size {
+			return fmt.Errorf("%s overflows %s", num, t.String())
+		}
+		return nil
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+	if num.Cmp(limit) >= 0 || num.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return fmt.Errorf("%s overflows %s", num, t.String())
+	}
+	return nil
+}
+
+// DecodePacked decodes abi.encodePacked data by the types. At most one type can be dynamic
+// (bytes, string or T[]), it takes all bytes left by the others.
+func DecodePacked(input string, data []byte) ([]abi.Type, []any, error) {
+	_, typeList, err := parseTypesInput(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	types := make([]abi.Type, len(typeList))
+	fixed, dynamic := 0, -1
+	for i, ty := range typeList {
+		if types[i], err = abi.NewType(ty, "", nil); err != nil {
+			return nil, nil, err
+		}
+		size, err := packedSize(types[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		if size < 0 {
+			if dynamic >= 0 {
+				return nil, nil, errors.New("packed data can only have one dynamic type, the size of others is unknown")
+			}
+			dynamic = i
+			continue
+		}
+		fixed += size
+	}
+	if fixed > len(data) || (dynamic < 0 && fixed != len(data)) {
+		return nil, nil, fmt.Errorf("data has %d bytes, types take %d bytes", len(data), fixed)
+	}
+	dynamicSize := len(data) - fixed
+	values := make([]any, len(types))
+	for i, t := range types {
+		size, _ := packedSize(t)
+		if i == dynamic {
+			size = dynamicSize
+		}
+		if values[i], err = unpackValue(t, data[:size], false); err != nil {
+			return nil, nil, fmt.Errorf("unpack arg[%d] (%s) failed: %w", i, t.String(), err)
+		}
+		data = data[size:]
+	}
+	return types, values, nil
+}
+
+// packedSize returns the size in packed encoding, -1 for dynamic types
+func packedSize(t abi.Type) (int, error) {
+	switch t.T {
+	case abi.StringTy, abi.BytesTy:
+		return -1, nil
+	case abi.SliceTy, abi.ArrayTy:
+		if t.Elem.T == abi.SliceTy || t.Elem.T == abi.ArrayTy || t.Elem.T == abi.StringTy || t.Elem.T == abi.BytesTy || t.Elem.T == abi.TupleTy {
+			return 0, fmt.Errorf("encodePacked does not support %s", t.String())
+		}
+		if t.T == abi.SliceTy {
+			return -1, nil
+		}
+		return t.Size * 32, nil
+	case abi.FixedBytesTy:
+		return t.Size, nil
+	case abi.AddressTy:
+		return 20, nil
+	case abi.BoolTy:
+		return 1, nil
+	case abi.IntTy, abi.UintTy:
+		return t.Size / 8, nil
+	}
+	return 0, fmt.Errorf("encodePacked does not support %s", t.String())
+}
+
+func unpackValue(t abi.Type, data []byte, inArray bool) (any, error) {
+	if inArray && t.T != abi.FixedBytesTy {
+		// array elements are left-padded words, except bytesN which is right-padded
+		data = data[32-packedSizeOrWord(t):]
+	}
+	switch t.T {
+	case abi.SliceTy, abi.ArrayTy:
+		if len(data)%32 != 0 {
+			return nil, fmt.Errorf("array data has %d bytes, not 32*N", len(data))
+		}
+		var elems []any
+		for i := 0; i < len(data); i += 32 {
+			elem, err := unpackValue(*t.Elem, data[i:i+32], true)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, elem)
+		}
+		return elems, nil
+	case abi.StringTy:
+		return string(data), nil
+	case abi.BytesTy:
+		return data, nil
+	case abi.FixedBytesTy:
+		arr := reflect.New(t.GetType()).Elem()
+		reflect.Copy(arr, reflect.ValueOf(data[:t.Size]))
+		return arr.Interface(), nil
+	case abi.AddressTy:
+		return common.BytesToAddress(data), nil
+	case abi.BoolTy:
+		return data[0] != 0, nil
+	case abi.IntTy, abi.UintTy:
+		num := new(big.Int).SetBytes(data)
+		if t.T == abi.IntTy && len(data) != 0 && data[0]&0x80 != 0 {
+			num.Sub(num, new(big.Int).Lsh(big.NewInt(1), uint(len(data)*8)))
+		}
+		return num, nil
+	}
+	return nil, fmt.Errorf("encodePacked does not support %s", t.String())
+}
+
+func packedSizeOrWord(t abi.Type) int {
+	if size, err := packedSize(t); err == nil && size > 0 && size <= 32 {
+		return size
+	}
+	return 32
+}
@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+/* ------------------------- Solidity declarations ------------------------- */
+
+// Declaration is a function, event or error declaration with the canonical signature.
+type Declaration struct {
+	Kind      string
+	Name      string
+	Signature string
+}
+
+// Selector is the first 4 bytes of keccak256(signature), used by functions and errors.
+func (d *Declaration) Selector() []byte {
+	return crypto.Keccak256([]byte(d.Signature))[:4]
+}
+
+// Topic is keccak256(signature), the topic0 of events.
+func (d *Declaration) Topic() []byte {
+	return crypto.Keccak256([]byte(d.Signature))
+}
+
+var (
+	declRe     = regexp.MustCompile(`\b(function|event|error|struct|enum)\s+([A-Za-z_$][\w$]*)\s*`)
+	typeDeclRe = regexp.MustCompile(`\btype\s+([A-Za-z_$][\w$]*)\s+is\s+([A-Za-z_$][\w$ ]*?)\s*;`)
+	contractRe = regexp.MustCompile(`\b(?:interface|contract)\s+([A-Za-z_$][\w$]*)`)
+	arrayRe    = regexp.MustCompile(`(\[\s*\d*\s*\])+$`)
+	spaceArrRe = regexp.MustCompile(`\s+\[`)
+)
+
+// sigTypes resolves user defined types: struct to its member types, enum and value types to theirs
+type sigTypes struct {
+	structs map[string][]string
+	aliases map[string]string
+}
+
+// ParseDeclarations parses solidity source (a whole interface block, or several declarations) into
+// canonical signatures of functions, events and errors. Struct, enum and user defined value types
+// in the source can be used in the params, so can contracts and interfaces (as address). The returned structs maps struct names to their tuple type.
+func ParseDeclarations(src string) (decls []*Declaration, structs map[string]string, err error) {
+	src = stripSolidityComments(src)
+	types := &sigTypes{structs: make(map[string][]string), aliases: make(map[string]string)}
+	for _, m := range typeDeclRe.FindAllStringSubmatch(src, -1) {
+		types.aliases[m[1]] = strings.TrimSpace(m[2])
+	}
+	// contract types are encoded as address
+	for _, m := range contractRe.FindAllStringSubmatch(src, -1) {
+		types.aliases[m[1]] = "address"
+	}
+	type pending struct {
+		kind, name, params string
+	}
+	var items []pending
+	for _, loc := range declRe.FindAllStringSubmatchIndex(src, -1) {
+		kind, name, rest := src[loc[2]:loc[3]], src[loc[4]:loc[5]], src[loc[1]:]
+		switch kind {
+		case "struct", "enum":
+			if !strings.HasPrefix(rest, "{") {
+				continue
+			}
+			end := strings.IndexByte(rest, '}')
+			if end < 0 {
+				return nil, nil, fmt.Errorf("unbalanced braces in %s %s", kind, name)
+			}
+			if kind == "enum" {
+				types.aliases[name] = "uint8"
+				continue
+			}
+			var members []string
+			for _, member := range strings.Split(rest[1:end], ";") {
+				if member = strings.TrimSpace(member); len(member) != 0 {
+					members = append(members, member)
+				}
+			}
+			types.structs[name] = members
+		default:
+			if !strings.HasPrefix(rest, "(") {
+				continue
+			}
+			params, err := takeUntilMatchingParen(rest[1:])
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, pending{kind, name, params})
+		}
+	}
+	structs = make(map[string]string)
+	for name := range types.structs {
+		if structs[name], err = types.canonical(name, 0); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, item := range items {
+		params, err := parseSolidityParams(item.params, types)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s %s: %w", item.kind, item.name, err)
+		}
+		signature := item.name + "(" + strings.Join(params, ",") + ")"
+		decls = append(decls, &Declaration{Kind: item.kind, Name: item.name, Signature: signature})
+	}
+	return decls, structs, nil
+}
+
+// CanonicalSignature converts `name(params)` with param names, `indexed`, data locations or
+// shorthand types like uint to the canonical form, e.g. `transfer(address to,uint amt)`.
+func CanonicalSignature(input string) (string, error) {
+	decls, _, err := ParseDeclarations("function " + strings.TrimSpace(input))
+	if err != nil {
+		return "", err
+	}
+	if len(decls) != 1 {
+		return "", fmt.Errorf("invalid signature: %q", input)
+	}
+	return decls[0].Signature, nil
+}
+
+// InterfaceID is the ERC-165 interface id, the xor of all function selectors.
+func InterfaceID(decls []*Declaration) []byte {
+	id := make([]byte, 4)
+	for _, decl := range decls {
+		if decl.Kind == "function" {
+			for i, b := range decl.Selector() {
+				id[i] ^= b
+			}
+		}
+	}
+	return id
+}
+
+// canonical converts the type, with optional array suffix, to the canonical ABI type. A nil t
+// resolves the elementary types only.
+func (t *sigTypes) canonical(ty string, depth int) (string, error) {
+	if depth > 32 {
+		return "", fmt.Errorf("recursive type %s", ty)
+	}
+	suffix := strings.ReplaceAll(arrayRe.FindString(ty), " ", "")
+	base := strings.TrimSpace(strings.TrimSuffix(ty, arrayRe.FindString(ty)))
+	if strings.HasPrefix(base, "(") {
+		inner := strings.TrimSuffix(strings.TrimPrefix(base, "("), ")")
+		types, err := parseSolidityParams(inner, t)
+		if err != nil {
+			return "", err
+		}
+		return "(" + strings.Join(types, ",") + ")" + suffix, nil
+	}
+	// qualified names like `IPool.Order`
+	if i := strings.LastIndexByte(base, '.'); i >= 0 {
+		base = base[i+1:]
+	}
+	switch base {
+	case "uint":
+		return "uint256" + suffix, nil
+	case "int":
+		return "int256" + suffix, nil
+	case "byte":
+		return "bytes1" + suffix, nil
+	case "fixed":
+		return "fixed128x18" + suffix, nil
+	case "ufixed":
+		return "ufixed128x18" + suffix, nil
+	case "address", "bool", "string", "bytes", "function":
+		return base + suffix, nil
+	}
+	if isElementaryType(base) {
+		return base + suffix, nil
+	}
+	if t == nil {
+		return "", fmt.Errorf("unknown type %s", base)
+	}
+	if members, ok := t.structs[base]; ok {
+		types := make([]string, 0, len(members))
+		for _, member := range members {
+			ty, err := solidityParamToABIType(member, t, depth+1)
+			if err != nil {
+				return "", fmt.Errorf("struct %s: %w", base, err)
+			}
+			types = append(types, ty)
+		}
+		return "(" + strings.Join(types, ",") + ")" + suffix, nil
+	}
+	if alias, ok := t.aliases[base]; ok {
+		ty, err := t.canonical(alias, depth+1)
+		return ty + suffix, err
+	}
+	return "", fmt.Errorf("unknown type %s, declare it as struct, enum, type or interface", base)
+}
+
+var elementaryRe = regexp.MustCompile(`^(u?int(8|16|24|32|40|48|56|64|72|80|88|96|104|112|120|128|136|144|152|160|168|176|184|192|200|208|216|224|232|240|248|256)|bytes([1-9]|[12]\d|3[0-2])|u?fixed\d+x\d+)$`)
+
+func isElementaryType(ty string) bool {
+	return elementaryRe.MatchString(ty)
+}
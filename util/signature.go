@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+/* ------------------------- Signature forms ------------------------- */
+
+const (
+	SigFormatRSV     = "r‖s‖v"
+	SigFormatEIP155  = "r‖s‖v (EIP-155)"
+	SigFormatCompact = "EIP-2098 compact"
+	SigFormatDER     = "DER"
+)
+
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// Signature is a secp256k1 signature, RecoveryId is -1 if unknown (DER has none).
+type Signature struct {
+	Format     string
+	R, S       *big.Int
+	V          *big.Int
+	RecoveryId int
+	ChainId    *big.Int
+}
+
+// ParseSignature accepts r‖s‖v with v of 0/1, 27/28 or EIP-155 chainId*2+35 (multi bytes for
+// large chain ids), 64 bytes EIP-2098 compact r‖yParityAndS, and DER.
+func ParseSignature(data []byte) (*Signature, error) {
+	var der struct{ R, S *big.Int }
+	if rest, err := asn1.Unmarshal(data, &der); err == nil && len(rest) == 0 {
+		return &Signature{Format: SigFormatDER, R: der.R, S: der.S, RecoveryId: -1}, nil
+	}
+	switch {
+	case len(data) == 64:
+		ys := new(big.Int).SetBytes(data[32:])
+		sig := &Signature{Format: SigFormatCompact, R: new(big.Int).SetBytes(data[:32]), RecoveryId: int(ys.Bit(255))}
+		sig.S = ys.SetBit(ys, 255, 0)
+		sig.V = big.NewInt(int64(sig.RecoveryId))
+		return sig, nil
+	case len(data) > 64 && len(data) <= 96:
+		sig := &Signature{
+			Format: SigFormatRSV,
+			R:      new(big.Int).SetBytes(data[:32]),
+			S:      new(big.Int).SetBytes(data[32:64]),
+			V:      new(big.Int).SetBytes(data[64:]),
+		}
+		switch v := sig.V; {
+		case v.IsUint64() && v.Uint64() <= 1:
+			sig.RecoveryId = int(v.Uint64())
+		case v.IsUint64() && (v.Uint64() == 27 || v.Uint64() == 28):
+			sig.RecoveryId = int(v.Uint64() - 27)
+		case v.Cmp(big.NewInt(35)) >= 0:
+			offset := new(big.Int).Sub(v, big.NewInt(35))
+			sig.Format = SigFormatEIP155
+			sig.RecoveryId = int(offset.Bit(0))
+			sig.ChainId = offset.Rsh(offset, 1)
+		default:
+			return nil, fmt.Errorf("invalid v %s", v)
+		}
+		return sig, nil
+	}
+	return nil, fmt.Errorf("unknown signature format of %d bytes", len(data))
+}
+
+// HighS tells if s is in the upper half of the curve order, the signature is malleable.
+func (s *Signature) HighS() bool {
+	return s.S.Cmp(secp256k1HalfN) > 0
+}
+
+// Normalize returns the low-s signature, the recovery id is flipped with s.
+func (s *Signature) Normalize() *Signature {
+	if !s.HighS() {
+		return s
+	}
+	low := *s
+	low.S = new(big.Int).Sub(secp256k1N, s.S)
+	if s.RecoveryId >= 0 {
+		low.RecoveryId = s.RecoveryId ^ 1
+	}
+	return &low
+}
+
+// RSV is the 65 bytes r‖s‖v, v is the recovery id plus vOffset (0 or 27).
+func (s *Signature) RSV(vOffset byte) ([]byte, error) {
+	if s.RecoveryId < 0 {
+		return nil, errors.New("recovery id is unknown")
+	}
+	return append(s.rs(), byte(s.RecoveryId)+vOffset), nil
+}
+
+// EIP155 is r‖s‖v with v = chainId*2+35+recoveryId.
+func (s *Signature) EIP155(chainId *big.Int) ([]byte, error) {
+	if s.RecoveryId < 0 {
+		return nil, errors.New("recovery id is unknown")
+	}
+	v := new(big.Int).Lsh(chainId, 1)
+	v.Add(v, big.NewInt(int64(35+s.RecoveryId)))
+	return append(s.rs(), v.Bytes()...), nil
+}
+
+// Compact is the EIP-2098 form, the recovery id is the top bit of s.
+func (s *Signature) Compact() ([]byte, error) {
+	if s.RecoveryId < 0 {
+		return nil, errors.New("recovery id is unknown")
+	}
+	if s.HighS() {
+		return nil, errors.New("compact form needs low-s")
+	}
+	data := s.rs()
+	data[32] |= byte(s.RecoveryId) << 7
+	return data, nil
+}
+
+func (s *Signature) DER() ([]byte, error) {
+	return asn1.Marshal(struct{ R, S *big.Int }{s.R, s.S})
+}
+
+func (s *Signature) rs() []byte {
+	return append(math.PaddedBigBytes(s.R, 32), math.PaddedBigBytes(s.S, 32)...)
+}
+
+// RecoverPubkey recovers the public key of the hash, high-s signatures are normalized first.
+func (s *Signature) RecoverPubkey(hash []byte) ([]byte, error) {
+	sig, err := s.Normalize().RSV(0)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Ecrecover(hash, sig)
+}
@@ -0,0 +1,234 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+/* ------------------------- Storage slot computation ------------------------- */
+
+// ComputeSlot evaluates solidity storage slot expressions:
+//
+//	"3"              plain slot
+//	"3[TR7NHq...]"   mapping key, see EncodeMappingKey for the key syntax
+//	"3[T...][0x01]"  nested mapping
+//	"4[#2]"          element 2 of dynamic array, "4[#2:3]" if each element takes 3 slots
+//	"3[T...]+1"      member offset of a struct
+//	"5[#0]"          first 32 bytes of a long (>= 32 bytes) bytes or string, it's stored like a byte array
+//
+// The base slot can also be a named slot like "eip1967.implementation" or an ERC-7201 namespace
+// like "erc7201:openzeppelin.storage.Ownable".
+func ComputeSlot(expr string) (common.Hash, error) {
+	expr = strings.TrimSpace(expr)
+	end := strings.IndexAny(expr, "[+")
+	if end < 0 {
+		end = len(expr)
+	}
+	slot, err := parseBaseSlot(strings.TrimSpace(expr[:end]))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	rest := expr[end:]
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '[':
+			inner, err := takeUntilMatchingBracket(rest[1:])
+			if err != nil {
+				return common.Hash{}, err
+			}
+			rest = rest[len(inner)+2:]
+			if strings.HasPrefix(inner, "#") {
+				index, size, err := parseArrayIndex(inner[1:])
+				if err != nil {
+					return common.Hash{}, err
+				}
+				slot = ArrayElementSlot(slot, index, size)
+			} else {
+				key, err := EncodeMappingKey(inner)
+				if err != nil {
+					return common.Hash{}, err
+				}
+				slot = MappingSlot(key, slot)
+			}
+		case '+':
+			end := strings.IndexAny(rest[1:], "[+")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			offset, ok := math.ParseBig256(strings.TrimSpace(rest[1 : end+1]))
+			if !ok {
+				return common.Hash{}, fmt.Errorf("invalid slot offset: %q", rest[1:end+1])
+			}
+			slot = AddSlot(slot, offset)
+			rest = rest[end+1:]
+		default:
+			return common.Hash{}, fmt.Errorf("unexpected %q in slot expression", rest)
+		}
+	}
+	return slot, nil
+}
+
+// NamedSlots are the well-known slots of proxies, EIP-1967 slots are keccak256(name) - 1,
+// the older EIP-1822 (UUPS) slot is keccak256("PROXIABLE").
+var NamedSlots = map[string]common.Hash{
+	"eip1967.implementation": eip1967Slot("eip1967.proxy.implementation"),
+	"eip1967.admin":          eip1967Slot("eip1967.proxy.admin"),
+	"eip1967.beacon":         eip1967Slot("eip1967.proxy.beacon"),
+	"eip1822.proxiable":      crypto.Keccak256Hash([]byte("PROXIABLE")),
+}
+
+func eip1967Slot(name string) common.Hash {
+	return AddSlot(crypto.Keccak256Hash([]byte(name)), big.NewInt(-1))
+}
+
+// ERC7201Slot is keccak256(abi.encode(uint256(keccak256(namespace)) - 1)) & ~bytes32(uint256(0xff)).
+func ERC7201Slot(namespace string) common.Hash {
+	slot := crypto.Keccak256Hash(eip1967Slot(namespace).Bytes())
+	slot[common.HashLength-1] = 0
+	return slot
+}
+
+func parseBaseSlot(s string) (common.Hash, error) {
+	if slot, ok := NamedSlots[strings.ToLower(s)]; ok {
+		return slot, nil
+	}
+	if namespace, ok := strings.CutPrefix(s, "erc7201:"); ok {
+		return ERC7201Slot(trimOptionalQuotes(strings.TrimSpace(namespace))), nil
+	}
+	base, ok := math.ParseBig256(s)
+	if !ok {
+		return common.Hash{}, fmt.Errorf("invalid base slot: %q", s)
+	}
+	return common.BigToHash(base), nil
+}
+
+// MappingSlot is keccak256(key . slot), key must be encoded by EncodeMappingKey.
+func MappingSlot(key []byte, slot common.Hash) common.Hash {
+	return crypto.Keccak256Hash(key, slot.Bytes())
+}
+
+// ArrayElementSlot is keccak256(slot) + index * size, elements are never packed here.
+func ArrayElementSlot(slot common.Hash, index uint64, size uint64) common.Hash {
+	offset := new(big.Int).Mul(new(big.Int).SetUint64(index), new(big.Int).SetUint64(size))
+	return AddSlot(crypto.Keccak256Hash(slot.Bytes()), offset)
+}
+
+// AddSlot adds offset to the slot, wrapped in 256 bits.
+func AddSlot(slot common.Hash, offset *big.Int) common.Hash {
+	sum := new(big.Int).Add(slot.Big(), offset)
+	return common.BigToHash(math.U256(sum))
+}
+
+// EncodeMappingKey encodes the mapping key the way solidity hashes it. The key can be typed
+// like "address:T...", "uint:5", "int:-1", "bool:true", "bytes4:0x12345678", "string:abc" or
+// "bytes:0x..", otherwise the type is guessed: TRON address, quoted string or number.
+func EncodeMappingKey(key string) ([]byte, error) {
+	key = strings.TrimSpace(key)
+	ty, value, typed := strings.Cut(key, ":")
+	if !typed || !isKeyType(ty) {
+		ty, value = guessKeyType(key), key
+	}
+	value = strings.TrimSpace(value)
+	switch {
+	case ty == "address":
+		addr, ok := ToAddress(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid address key: %s", value)
+		}
+		return common.LeftPadBytes(addr, 32), nil
+	case ty == "bool":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bool key: %s", value)
+		}
+		if b {
+			return common.LeftPadBytes([]byte{1}, 32), nil
+		}
+		return make([]byte, 32), nil
+	case ty == "string":
+		return []byte(trimOptionalQuotes(value)), nil
+	case ty == "bytes":
+		if data, ok := FromHex(value); ok {
+			return data, nil
+		}
+		return []byte(trimOptionalQuotes(value)), nil
+	case strings.HasPrefix(ty, "bytes"):
+		data, ok := FromHex(value)
+		if !ok || len(data) > 32 {
+			return nil, fmt.Errorf("invalid %s key: %s", ty, value)
+		}
+		return common.RightPadBytes(data, 32), nil
+	default:
+		num, err := parseBigIntExtended(value, strings.HasPrefix(ty, "uint"))
+		if err != nil {
+			return nil, err
+		}
+		if num.BitLen() > 256 {
+			return nil, fmt.Errorf("int key overflows 256 bits: %s", value)
+		}
+		return math.U256Bytes(num), nil
+	}
+}
+
+func isKeyType(ty string) bool {
+	switch ty {
+	case "address", "bool", "string", "bytes", "uint", "int":
+		return true
+	}
+	for _, prefix := range []string{"uint", "int", "bytes"} {
+		if size, err := strconv.Atoi(strings.TrimPrefix(ty, prefix)); err == nil && strings.HasPrefix(ty, prefix) {
+			return size > 0 && size <= 256
+		}
+	}
+	return false
+}
+
+func guessKeyType(key string) string {
+	if len(key) >= 2 && (key[0] == '"' || key[0] == '\'') {
+		return "string"
+	}
+	if len(key) == 34 && key[0] == 'T' {
+		return "address"
+	}
+	if strings.EqualFold(key, "true") || strings.EqualFold(key, "false") {
+		return "bool"
+	}
+	return "int"
+}
+
+func parseArrayIndex(s string) (index uint64, size uint64, err error) {
+	indexStr, sizeStr, hasSize := strings.Cut(s, ":")
+	if index, err = strconv.ParseUint(strings.TrimSpace(indexStr), 0, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid array index: %q", indexStr)
+	}
+	size = 1
+	if hasSize {
+		if size, err = strconv.ParseUint(strings.TrimSpace(sizeStr), 0, 64); err != nil || size == 0 {
+			return 0, 0, fmt.Errorf("invalid array element size: %q", sizeStr)
+		}
+	}
+	return index, size, nil
+}
+
+// after "[" already consumed, return content until the matching "]"
+func takeUntilMatchingBracket(s string) (string, error) {
+	depth := 1
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return s[:i], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unbalanced brackets in slot expression")
+}
@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+/* ------------------------- EIP-712 typed data ------------------------- */
+
+// TypedDataHashes are the intermediate hashes of typed data, Digest is the one to sign.
+type TypedDataHashes struct {
+	DomainType      string
+	DomainSeparator []byte
+	PrimaryType     string
+	TypeHash        []byte
+	StructHash      []byte
+	Digest          []byte
+}
+
+// tronChainIdMask keeps the last 4 bytes of the genesis block hash, which is `block.chainid` in TVM
+var tronChainIdMask = big.NewInt(0xffffffff)
+
+// LoadTypedData parses the `eth_signTypedData_v4` json. The EIP712Domain type is derived from the
+// domain if missing. For TIP-712, base58 addresses in the domain and message are converted to hex
+// and the chainId is masked; it's enabled by tron, or by any base58 address found.
+func LoadTypedData(data []byte, tron bool) (*apitypes.TypedData, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var raw struct {
+		Types       apitypes.Types         `json:"types"`
+		PrimaryType string                 `json:"primaryType"`
+		Domain      map[string]interface{} `json:"domain"`
+		Message     map[string]interface{} `json:"message"`
+	}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	if len(raw.PrimaryType) == 0 {
+		return nil, fmt.Errorf("primaryType is missing")
+	}
+	if _, ok := raw.Types[raw.PrimaryType]; !ok {
+		return nil, fmt.Errorf("primary type %s is not defined in types", raw.PrimaryType)
+	}
+	if _, ok := raw.Types["EIP712Domain"]; !ok {
+		raw.Types["EIP712Domain"] = domainType(raw.Domain)
+	}
+	converted := false
+	domain, err := convertTypedValue(raw.Types, "EIP712Domain", normalizeTypedValue(raw.Domain), &converted)
+	if err != nil {
+		return nil, fmt.Errorf("domain: %w", err)
+	}
+	message, err := convertTypedValue(raw.Types, raw.PrimaryType, normalizeTypedValue(raw.Message), &converted)
+	if err != nil {
+		return nil, fmt.Errorf("message: %w", err)
+	}
+	typed := &apitypes.TypedData{
+		Types:       raw.Types,
+		PrimaryType: raw.PrimaryType,
+		Message:     message.(map[string]interface{}),
+	}
+	for key, value := range domain.(map[string]interface{}) {
+		text, _ := value.(string)
+		switch key {
+		case "name":
+			typed.Domain.Name = text
+		case "version":
+			typed.Domain.Version = text
+		case "verifyingContract":
+			typed.Domain.VerifyingContract = text
+		case "salt":
+			typed.Domain.Salt = text
+		case "chainId":
+			var chainId math.HexOrDecimal256
+			if err := chainId.UnmarshalText([]byte(text)); err != nil {
+				return nil, fmt.Errorf("invalid chainId %v", value)
+			}
+			if tron || converted {
+				(*big.Int)(&chainId).And((*big.Int)(&chainId), tronChainIdMask)
+			}
+			typed.Domain.ChainId = &chainId
+		}
+	}
+	return typed, nil
+}
+
+// HashTypedData computes the domain separator, the struct hash of the message and the digest
+// keccak256("\x19\x01" ‖ domainSeparator ‖ structHash).
+func HashTypedData(typed *apitypes.TypedData) (*TypedDataHashes, error) {
+	domainSeparator, err := typed.HashStruct("EIP712Domain", typed.Domain.Map())
+	if err != nil {
+		return nil, fmt.Errorf("hash domain failed: %w", err)
+	}
+	structHash, err := typed.HashStruct(typed.PrimaryType, typed.Message)
+	if err != nil {
+		return nil, fmt.Errorf("hash message failed: %w", err)
+	}
+	return &TypedDataHashes{
+		DomainType:      string(typed.EncodeType("EIP712Domain")),
+		DomainSeparator: domainSeparator,
+		PrimaryType:     string(typed.EncodeType(typed.PrimaryType)),
+		TypeHash:        typed.TypeHash(typed.PrimaryType),
+		StructHash:      structHash,
+		Digest:          crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, structHash),
+	}, nil
+}
+
+// domainType lists the domain fields present, in the order of EIP-712
+func domainType(domain map[string]interface{}) []apitypes.Type {
+	var fields []apitypes.Type
+	for _, field := range []apitypes.Type{
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+		{Name: "salt", Type: "bytes32"},
+	} {
+		if _, ok := domain[field.Name]; ok {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// normalizeTypedValue turns json numbers into strings, apitypes parses them without losing precision
+func normalizeTypedValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		return v.String()
+	case map[string]interface{}:
+		for key, elem := range v {
+			v[key] = normalizeTypedValue(elem)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = normalizeTypedValue(elem)
+		}
+	}
+	return value
+}
+
+// convertTypedValue converts base58 (or 0x41 prefixed) addresses of the type to hex recursively
+func convertTypedValue(types apitypes.Types, typeName string, value interface{}, converted *bool) (interface{}, error) {
+	if i := strings.LastIndexByte(typeName, '['); i > 0 {
+		elems, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s expects array, got %v", typeName, value)
+		}
+		for j, elem := range elems {
+			var err error
+			if elems[j], err = convertTypedValue(types, typeName[:i], elem, converted); err != nil {
+				return nil, err
+			}
+		}
+		return elems, nil
+	}
+	if typeName == "address" {
+		text, ok := value.(string)
+		if !ok {
+			return value, nil
+		}
+		if len(text) == 34 && text[0] == 'T' {
+			addr, ok := ToAddress(text)
+			if !ok {
+				return nil, fmt.Errorf("invalid address %s", text)
+			}
+			*converted = true
+			return common.BytesToAddress(addr).Hex(), nil
+		}
+		if addr, ok := FromHex(text); ok && len(addr) == 21 && addr[0] == 0x41 {
+			*converted = true
+			return common.BytesToAddress(addr[1:]).Hex(), nil
+		}
+		return value, nil
+	}
+	fields, ok := types[typeName]
+	if !ok {
+		return value, nil
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s expects object, got %v", typeName, value)
+	}
+	for _, field := range fields {
+		elem, ok := object[field.Name]
+		if !ok {
+			continue
+		}
+		var err error
+		if object[field.Name], err = convertTypedValue(types, field.Type, elem, converted); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", typeName, field.Name, err)
+		}
+	}
+	return object, nil
+}
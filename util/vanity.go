@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync/atomic"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+/* ------------------------- Vanity addresses ------------------------- */
+
+const (
+	base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	// base58AddrLen is the length of base58(0x41 ‖ addr ‖ checksum)
+	base58AddrLen = 34
+	// vanityBatch is the number of attempts between the checks of stop and the progress updates
+	vanityBatch = 256
+)
+
+var (
+	// the 25 bytes values of base58 TRON addresses are in [0x41 ‖ 00.., 0x41 ‖ ff..]
+	minAddrValue = new(big.Int).Lsh(big.NewInt(0x41), 192)
+	maxAddrValue = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(0x42), 192), big.NewInt(1))
+)
+
+// VanityPattern matches the prefix and suffix of base58 TRON addresses.
+type VanityPattern struct {
+	Prefix     string
+	Suffix     string
+	IgnoreCase bool
+}
+
+func (p *VanityPattern) Match(addr string) bool {
+	if len(addr) < len(p.Prefix)+len(p.Suffix) {
+		return false
+	}
+	prefix, suffix := addr[:len(p.Prefix)], addr[len(addr)-len(p.Suffix):]
+	if p.IgnoreCase {
+		return strings.EqualFold(prefix, p.Prefix) && strings.EqualFold(suffix, p.Suffix)
+	}
+	return prefix == p.Prefix && suffix == p.Suffix
+}
+
+// Difficulty returns the expected attempts to match, it errors if the pattern can't be matched.
+// The prefix is limited by the range of addresses (T and the second char), the suffix is in the
+// checksum and can be any base58 chars.
+func (p *VanityPattern) Difficulty() (float64, error) {
+	if len(p.Prefix) == 0 && len(p.Suffix) == 0 {
+		return 0, errors.New("prefix or suffix is needed")
+	}
+	if len(p.Prefix)+len(p.Suffix) > base58AddrLen {
+		return 0, fmt.Errorf("prefix and suffix are longer than %d chars", base58AddrLen)
+	}
+	prefixChars, err := p.variants(p.Prefix)
+	if err != nil {
+		return 0, err
+	}
+	suffixChars, err := p.variants(p.Suffix)
+	if err != nil {
+		return 0, err
+	}
+
+	// sum the ranges of all prefix variants, the ones out of the address range are pruned
+	matched := new(big.Int)
+	var walk func(i int, low *big.Int)
+	walk = func(i int, low *big.Int) {
+		width := new(big.Int).Exp(big.NewInt(58), big.NewInt(int64(base58AddrLen-i)), nil)
+		high := new(big.Int).Add(low, width)
+		high.Sub(high, big.NewInt(1))
+		if high.Cmp(minAddrValue) < 0 || low.Cmp(maxAddrValue) > 0 {
+			return
+		}
+		if i == len(prefixChars) {
+			lo, hi := bigMax(low, minAddrValue), bigMin(high, maxAddrValue)
+			matched.Add(matched, new(big.Int).Sub(hi, lo))
+			matched.Add(matched, big.NewInt(1))
+			return
+		}
+		width.Div(width, big.NewInt(58))
+		for _, digit := range prefixChars[i] {
+			walk(i+1, new(big.Int).Add(low, new(big.Int).Mul(width, big.NewInt(int64(digit)))))
+		}
+	}
+	walk(0, new(big.Int))
+	if matched.Sign() == 0 {
+		return 0, fmt.Errorf("prefix %s is unreachable, base58 addresses are between %s and %s",
+			p.Prefix, base58.Encode(minAddrValue.Bytes()), base58.Encode(maxAddrValue.Bytes()))
+	}
+	total := new(big.Int).Sub(maxAddrValue, minAddrValue)
+	total.Add(total, big.NewInt(1))
+	attempts, _ := new(big.Float).Quo(new(big.Float).SetInt(total), new(big.Float).SetInt(matched)).Float64()
+	for _, chars := range suffixChars {
+		attempts = attempts * 58 / float64(len(chars))
+	}
+	return attempts, nil
+}
+
+// variants returns the base58 digits each char can be, both cases if IgnoreCase.
+func (p *VanityPattern) variants(s string) ([][]int, error) {
+	digits := make([][]int, 0, len(s))
+	for _, c := range s {
+		candidates := []rune{c}
+		if p.IgnoreCase {
+			candidates = []rune{[]rune(strings.ToLower(string(c)))[0], []rune(strings.ToUpper(string(c)))[0]}
+		}
+		var options []int
+		for i, candidate := range candidates {
+			if i == 1 && candidate == candidates[0] {
+				break
+			}
+			if digit := strings.IndexRune(base58Alphabet, candidate); digit >= 0 {
+				options = append(options, digit)
+			}
+		}
+		if len(options) == 0 {
+			return nil, fmt.Errorf("%q is not a base58 char, base58 has no 0, O, I and l", c)
+		}
+		digits = append(digits, options)
+	}
+	return digits, nil
+}
+
+// VanityResult is the matched address with its private key, or with the salt of CREATE2.
+type VanityResult struct {
+	Address []byte
+	Key     *ecdsa.PrivateKey
+	Salt    []byte
+}
+
+// VanityCreate2 searches salts of the TRON CREATE2 address instead of private keys.
+type VanityCreate2 struct {
+	Deployer     []byte
+	InitCodeHash []byte
+}
+
+// SearchVanity runs the workers until an address matches, attempts is added for the progress.
+// Private keys are generated with crypto/rand, the CREATE2 salts count up from a crypto/rand one.
+func SearchVanity(pattern *VanityPattern, workers int, create2 *VanityCreate2, attempts *atomic.Int64) (*VanityResult, error) {
+	if workers < 1 {
+		return nil, errors.New("workers should be at least 1")
+	}
+	done := make(chan struct{})
+	defer close(done)
+	// buffered so the workers never block after done
+	results := make(chan *VanityResult, workers)
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			next, err := vanityGenerator(create2)
+			if err != nil {
+				errs <- err
+				return
+			}
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				for j := 0; j < vanityBatch; j++ {
+					result, err := next()
+					if err != nil {
+						errs <- err
+						return
+					}
+					if pattern.Match(ToBase58(result.Address)) {
+						results <- result
+						return
+					}
+				}
+				attempts.Add(vanityBatch)
+			}
+		}()
+	}
+	select {
+	case result := <-results:
+		return result, nil
+	case err := <-errs:
+		return nil, err
+	}
+}
+
+func vanityGenerator(create2 *VanityCreate2) (func() (*VanityResult, error), error) {
+	if create2 == nil {
+		return func() (*VanityResult, error) {
+			key, err := crypto.GenerateKey()
+			if err != nil {
+				return nil, err
+			}
+			return &VanityResult{Address: crypto.PubkeyToAddress(key.PublicKey).Bytes(), Key: key}, nil
+		}, nil
+	}
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return func() (*VanityResult, error) {
+		binary.BigEndian.PutUint64(salt[24:], binary.BigEndian.Uint64(salt[24:])+1)
+		addr := Create2Address(Create2PrefixTRON, create2.Deployer, salt, create2.InitCodeHash)
+		return &VanityResult{Address: addr, Salt: append([]byte(nil), salt...)}, nil
+	}, nil
+}
+
+func bigMax(a, b *big.Int) *big.Int {
+	if a.Cmp(b) > 0 {
+		return a
+	}
+	return b
+}
+
+func bigMin(a, b *big.Int) *big.Int {
+	if a.Cmp(b) < 0 {
+		return a
+	}
+	return b
+}